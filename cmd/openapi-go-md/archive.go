@@ -0,0 +1,173 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dmoose/openApiGo/pkg/markdown"
+)
+
+// defaultArchiveEntries are the spec file names looked for in an archive when
+// --entry is not given, in order of preference.
+var defaultArchiveEntries = []string{
+	"openapi.yaml", "openapi.yml", "openapi.json",
+	"swagger.yaml", "swagger.yml", "swagger.json",
+}
+
+// isZipArchive reports whether the input is a zip archive, judged by the file
+// extension or the zip local file header magic.
+func isZipArchive(name string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(name), ".zip") || bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// convertArchive converts the entry spec of a zip archive to each format.
+func convertArchive(ctx context.Context, data []byte, entry string, opts markdown.Options, formats []markdown.OutputFormat) (map[markdown.OutputFormat]string, error) {
+	var out map[markdown.OutputFormat]string
+	err := withArchiveSpec(data, entry, opts, func(spec []byte, opts markdown.Options) (err error) {
+		out, err = markdown.ConvertAllContext(ctx, spec, opts, formats)
+		return err
+	})
+	return out, err
+}
+
+// summarizeArchive counts the pieces of the entry spec of a zip archive.
+func summarizeArchive(ctx context.Context, data []byte, entry string, opts markdown.Options) (markdown.Summary, error) {
+	var sum markdown.Summary
+	err := withArchiveSpec(data, entry, opts, func(spec []byte, opts markdown.Options) (err error) {
+		sum, err = markdown.SummarizeContext(ctx, spec, opts)
+		return err
+	})
+	return sum, err
+}
+
+// withArchiveSpec extracts a zip archive to a temporary directory and calls
+// fn with its entry spec and BaseDir set to the entry's directory, so a
+// root-level $ref and the spec's refs to sibling files resolve within the
+// archive. The directory is removed afterwards.
+func withArchiveSpec(data []byte, entry string, opts markdown.Options, fn func(spec []byte, opts markdown.Options) error) error {
+	dir, err := os.MkdirTemp("", "openapi-go-md-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractZip(data, dir); err != nil {
+		return err
+	}
+	path, err := findArchiveEntry(dir, entry)
+	if err != nil {
+		return err
+	}
+	spec, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	opts.BaseDir = filepath.Dir(path)
+	return fn(spec, opts)
+}
+
+// Caps on the uncompressed size of an archive, per entry and in total, so a
+// zip bomb fails instead of filling the disk. Spec archives are far smaller.
+var (
+	maxArchiveEntrySize int64 = 64 << 20
+	maxArchiveTotalSize int64 = 256 << 20
+)
+
+// extractZip writes the files of a zip archive under dir, failing on entries
+// that escape dir or once maxArchiveEntrySize or maxArchiveTotalSize is
+// exceeded. Sizes are counted as entries are read, not taken from the
+// archive's headers.
+func extractZip(data []byte, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("open zip archive: %w", err)
+	}
+	var total int64
+	for _, f := range zr.File {
+		dest := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(dest, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("zip entry %q escapes the archive root", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(dest, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("read zip entry %q: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxArchiveEntrySize+1))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("read zip entry %q: %w", f.Name, err)
+		}
+		if int64(len(content)) > maxArchiveEntrySize {
+			return fmt.Errorf("zip entry %q is larger than %d bytes uncompressed", f.Name, maxArchiveEntrySize)
+		}
+		if total += int64(len(content)); total > maxArchiveTotalSize {
+			return fmt.Errorf("zip archive is larger than %d bytes uncompressed", maxArchiveTotalSize)
+		}
+		if err := os.WriteFile(dest, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findArchiveEntry locates the root spec in an extracted archive. An explicit
+// entry is taken relative to dir; otherwise the shallowest file matching
+// defaultArchiveEntries wins, with ties broken by preference then path.
+func findArchiveEntry(dir, entry string) (string, error) {
+	if entry != "" {
+		path := filepath.Join(dir, filepath.FromSlash(entry))
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("entry %q not found in archive", entry)
+		}
+		return path, nil
+	}
+
+	rank := make(map[string]int, len(defaultArchiveEntries))
+	for i, name := range defaultArchiveEntries {
+		rank[name] = i
+	}
+	var found []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if _, ok := rank[strings.ToLower(d.Name())]; ok && !d.IsDir() {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("no spec found in archive (looked for %s); use --entry", strings.Join(defaultArchiveEntries, ", "))
+	}
+	sort.Slice(found, func(i, j int) bool {
+		di, dj := strings.Count(found[i], string(os.PathSeparator)), strings.Count(found[j], string(os.PathSeparator))
+		if di != dj {
+			return di < dj
+		}
+		ri, rj := rank[strings.ToLower(filepath.Base(found[i]))], rank[strings.ToLower(filepath.Base(found[j]))]
+		if ri != rj {
+			return ri < rj
+		}
+		return found[i] < found[j]
+	})
+	return found[0], nil
+}
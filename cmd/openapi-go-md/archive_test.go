@@ -0,0 +1,133 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dmoose/openApiGo/pkg/markdown"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry %q: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write zip entry %q: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+const archiveSpec = `{"openapi": "3.0.3", "info": {"title": "Archived API", "version": "1.0.0"}, "paths": {}}`
+
+func TestConvertArchive(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"bundle/openapi.json":   `{"$ref": "specs/api.json"}`,
+		"bundle/specs/api.json": archiveSpec,
+		"bundle/README.txt":     "not a spec",
+	})
+	if !isZipArchive("bundle.bin", data) {
+		t.Fatalf("expected zip magic to be detected")
+	}
+
+	formats := []markdown.OutputFormat{markdown.OutputMarkdown}
+	out, err := convertArchive(context.Background(), data, "", markdown.Options{}, formats)
+	md := out[markdown.OutputMarkdown]
+	if err != nil {
+		t.Fatalf("convertArchive returned error: %v", err)
+	}
+	if !strings.Contains(md, "# Archived API") {
+		t.Fatalf("expected archive entry (via its root $ref) to be converted, got: %s", md)
+	}
+
+	out, err = convertArchive(context.Background(), data, "bundle/specs/api.json", markdown.Options{}, formats)
+	md = out[markdown.OutputMarkdown]
+	if err != nil || !strings.Contains(md, "# Archived API") {
+		t.Fatalf("convertArchive with --entry = (%q, %v)", md, err)
+	}
+
+	if _, err := convertArchive(context.Background(), data, "missing.yaml", markdown.Options{}, formats); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected missing entry error, got %v", err)
+	}
+	if _, err := convertArchive(context.Background(), buildZip(t, map[string]string{"notes.txt": "x"}), "", markdown.Options{}, formats); err == nil || !strings.Contains(err.Error(), "no spec found") {
+		t.Fatalf("expected no spec found error, got %v", err)
+	}
+	if _, err := convertArchive(context.Background(), buildZip(t, map[string]string{"../openapi.json": archiveSpec}), "", markdown.Options{}, formats); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected escaping entry to be rejected, got %v", err)
+	}
+}
+
+func TestConvertArchiveExternalRefs(t *testing.T) {
+	const petSchema = "Pet:\n  type: object\n  properties:\n    nickname:\n      type: string\n"
+	const refSpec = `openapi: 3.0.3
+info: {title: Pet API, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Pet:
+      $ref: "./schemas/pet.yaml#/Pet"
+`
+	cases := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"sibling file", map[string]string{
+			"openapi.yaml":     refSpec,
+			"schemas/pet.yaml": petSchema,
+		}},
+		{"after a root $ref", map[string]string{
+			"openapi.json":           `{"$ref": "specs/api.yaml"}`,
+			"specs/api.yaml":         refSpec,
+			"specs/schemas/pet.yaml": petSchema,
+		}},
+	}
+	formats := []markdown.OutputFormat{markdown.OutputMarkdown}
+	for _, tc := range cases {
+		out, err := convertArchive(context.Background(), buildZip(t, tc.files), "", markdown.Options{}, formats)
+		if err != nil {
+			t.Fatalf("%s: convertArchive returned error: %v", tc.name, err)
+		}
+		if md := out[markdown.OutputMarkdown]; !strings.Contains(md, "nickname") {
+			t.Fatalf("%s: expected the referenced schema's properties, got: %s", tc.name, md)
+		}
+	}
+}
+
+func TestSummarizeArchive(t *testing.T) {
+	data := buildZip(t, map[string]string{"openapi.json": archiveSpec})
+	sum, err := summarizeArchive(context.Background(), data, "", markdown.Options{})
+	if err != nil {
+		t.Fatalf("summarizeArchive returned error: %v", err)
+	}
+	if sum != (markdown.Summary{}) {
+		t.Fatalf("expected an empty summary for a spec without paths, got %+v", sum)
+	}
+}
+
+func TestExtractZipSizeLimits(t *testing.T) {
+	defer func(entry, total int64) { maxArchiveEntrySize, maxArchiveTotalSize = entry, total }(maxArchiveEntrySize, maxArchiveTotalSize)
+	maxArchiveEntrySize, maxArchiveTotalSize = 100, 150
+
+	big := strings.Repeat("a", 101)
+	if err := extractZip(buildZip(t, map[string]string{"openapi.json": big}), t.TempDir()); err == nil || !strings.Contains(err.Error(), "larger than 100 bytes") {
+		t.Fatalf("expected an oversized entry to be rejected, got %v", err)
+	}
+	half := strings.Repeat("a", 80)
+	if err := extractZip(buildZip(t, map[string]string{"a.json": half, "b.json": half}), t.TempDir()); err == nil || !strings.Contains(err.Error(), "archive is larger than 150 bytes") {
+		t.Fatalf("expected an oversized archive to be rejected, got %v", err)
+	}
+	if err := extractZip(buildZip(t, map[string]string{"a.json": half}), t.TempDir()); err != nil {
+		t.Fatalf("expected an archive within the limits to extract, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dmoose/openApiGo/pkg/markdown"
+)
+
+// convertDir converts every spec under dir with markdown.ConvertDir and
+// writes each output to outDir at its path relative to dir: dir/billing/
+// invoices.yaml becomes outDir/billing/invoices.md. Directories are created
+// as needed. With dryRun nothing is written. It returns the output files in
+// path order and the relative paths of skipped non-spec files. Nothing is
+// written when any spec fails to convert.
+func convertDir(ctx context.Context, dir, outDir string, opts markdown.Options, formats []markdown.OutputFormat, dryRun bool) ([]outputFile, []string, error) {
+	outputs, skipped, err := markdown.ConvertDir(ctx, dir, opts, formats)
+	if err != nil {
+		return nil, skipped, err
+	}
+	rels := make([]string, 0, len(outputs))
+	for rel := range outputs {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	files := make([]outputFile, 0, len(rels))
+	for _, rel := range rels {
+		files = append(files, outputFile{path: filepath.Join(outDir, filepath.FromSlash(rel)), content: outputs[rel]})
+	}
+	if dryRun {
+		return files, skipped, nil
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			return nil, skipped, err
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), 0o644); err != nil {
+			return nil, skipped, err
+		}
+	}
+	return files, skipped, nil
+}
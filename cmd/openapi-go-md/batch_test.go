@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmoose/openApiGo/pkg/markdown"
+)
+
+func TestConvertDir(t *testing.T) {
+	in := t.TempDir()
+	files := map[string]string{
+		"root.json":                `{"openapi": "3.0.3", "info": {"title": "Root API", "version": "1.0.0"}, "paths": {}}`,
+		"billing/invoices.yaml":    "openapi: 3.0.3\ninfo:\n  title: Invoices API\n  version: 1.0.0\npaths: {}\n",
+		"billing/legacy/old.json":  `{"swagger": "2.0", "info": {"title": "Legacy API", "version": "1.0.0"}, "paths": {}}`,
+		"billing/legacy/notes.txt": "not a spec",
+	}
+	for name, content := range files {
+		path := filepath.Join(in, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "docs")
+	written, skipped, err := convertDir(context.Background(), in, out, markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown}, false)
+	if err != nil {
+		t.Fatalf("convertDir returned error: %v", err)
+	}
+	want := map[string]string{
+		"billing/invoices.md":   "# Invoices API",
+		"billing/legacy/old.md": "# Legacy API",
+		"root.md":               "# Root API",
+	}
+	if len(written) != len(want) {
+		t.Fatalf("expected %d files written, got %v", len(want), written)
+	}
+	if len(skipped) != 0 {
+		t.Fatalf("expected no skipped files, got %v", skipped)
+	}
+	for name, title := range want {
+		md, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", name, err)
+		}
+		if !strings.Contains(string(md), title) {
+			t.Fatalf("expected %s to contain %q, got:\n%s", name, title, md)
+		}
+	}
+
+	// Several formats are written side by side.
+	out = t.TempDir()
+	written, _, err = convertDir(context.Background(), filepath.Join(in, "billing", "legacy"), out, markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown, markdown.OutputJSONL}, false)
+	if err != nil {
+		t.Fatalf("convertDir returned error: %v", err)
+	}
+	if len(written) != 2 || filepath.Base(written[0].path) != "old.jsonl" || filepath.Base(written[1].path) != "old.md" {
+		t.Fatalf("expected old.jsonl and old.md, got %v", written)
+	}
+
+	// A dry run reports the files without writing them.
+	out = filepath.Join(t.TempDir(), "docs")
+	planned, _, err := convertDir(context.Background(), in, out, markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown}, true)
+	if err != nil {
+		t.Fatalf("convertDir returned error: %v", err)
+	}
+	if len(planned) != len(want) || planned[0].path != filepath.Join(out, "billing", "invoices.md") || planned[0].content == "" {
+		t.Fatalf("expected %d planned files starting with billing/invoices.md, got %v", len(want), planned)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("expected a dry run not to create %s, got %v", out, err)
+	}
+
+	// A spec that fails to convert is reported by its relative path.
+	if err := os.WriteFile(filepath.Join(in, "billing", "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = convertDir(context.Background(), in, t.TempDir(), markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown}, false)
+	if err == nil || !strings.Contains(err.Error(), filepath.Join("billing", "broken.json")) {
+		t.Fatalf("expected an error naming billing/broken.json, got %v", err)
+	}
+}
+
+func TestConvertDirSkipsNonSpecFiles(t *testing.T) {
+	in := t.TempDir()
+	files := map[string]string{
+		"api.yaml":                 "openapi: 3.0.3\ninfo:\n  title: Mixed API\n  version: 1.0.0\npaths: {}\n",
+		"package.json":             `{"name": "docs", "version": "1.0.0"}`,
+		".github/workflows/ci.yml": "on: push\njobs:\n  test:\n    runs-on: ubuntu-latest\n",
+		"list.json":                `[1, 2, 3]`,
+		"notes.yaml":               "- not a spec\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(in, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := t.TempDir()
+	written, skipped, err := convertDir(context.Background(), in, out, markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown}, false)
+	if err != nil {
+		t.Fatalf("convertDir returned error: %v", err)
+	}
+	if len(written) != 1 || written[0].path != filepath.Join(out, "api.md") {
+		t.Fatalf("expected only api.md to be written, got %v", written)
+	}
+	want := []string{".github/workflows/ci.yml", "list.json", "notes.yaml", "package.json"}
+	if strings.Join(skipped, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected skipped %v, got %v", want, skipped)
+	}
+
+	// A file that does not parse may be a broken spec, so it is not skipped.
+	if err := os.WriteFile(filepath.Join(in, "draft.yaml"), []byte("openapi: 3.0.3\n  : [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := convertDir(context.Background(), in, t.TempDir(), markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown}, false); err == nil || !strings.Contains(err.Error(), "draft.yaml") {
+		t.Fatalf("expected an error naming draft.yaml, got %v", err)
+	}
+}
@@ -1,27 +1,77 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/dmoose/openApiGo/pkg/markdown"
 )
 
 func main() {
 	var (
-		fileFlag   string
-		urlFlag    string
-		outFlag    string
-		formatFlag string
+		fileFlag     string
+		urlFlag      string
+		outFlag      string
+		formatFlag   string
+		validateFlag string
+		widthFlag    int
+		indentFlag   int
+		docFlag      int
+		checkFlag    bool
+		dryRunFlag   bool
+		entryFlag    string
+		noSchemas    bool
+		verboseFlag  bool
+		methodsFlag  string
+		outputFlag   string
+		encodingFlag string
+		reuseParams  bool
+		reuseBodies  bool
+		crlfFlag     bool
+		countFlag    bool
+		dirFlag      string
+		outDirFlag   string
+		cpuProfile   string
+		memProfile   string
+		timeoutFlag  time.Duration
 	)
 
 	flag.StringVar(&fileFlag, "file", "", "Path to OpenAPI spec file ('-' for stdin)")
-	flag.StringVar(&urlFlag, "url", "", "URL to OpenAPI spec")
+	flag.StringVar(&urlFlag, "url", "", "URL to OpenAPI spec, or github:owner/repo@ref:path for a file in a GitHub repository")
 	flag.StringVar(&outFlag, "out", "", "Output file path (defaults to stdout)")
+	flag.StringVar(&dirFlag, "dir", "", "Convert every .json, .yaml, and .yml spec under this directory (requires --out-dir)")
+	flag.StringVar(&outDirFlag, "out-dir", "", "With --dir, write each spec to this directory at its path relative to --dir")
 	flag.StringVar(&formatFlag, "format", "auto", "Input format: auto|json|yaml")
+	flag.IntVar(&docFlag, "document", 0, "1-based document to convert from a multi-document YAML input (default: the one spec document)")
+	flag.StringVar(&encodingFlag, "input-encoding", "utf-8", "Input text encoding: utf-8|utf-16|utf-16le|utf-16be|iso-8859-1 (a UTF-16 BOM is always honored)")
+	flag.StringVar(&validateFlag, "validate", "auto", "OpenAPI 3 validation: auto|off|strict")
+	flag.IntVar(&widthFlag, "summary-width", 0, "Wrap long description lines at this column (0 disables wrapping)")
+	flag.IntVar(&indentFlag, "indent", markdown.DefaultIndent, "Spaces per level for nested list items")
+	flag.BoolVar(&checkFlag, "check", false, "Compare the rendering with --out instead of writing it; print a diff and exit 1 if they differ")
+	flag.BoolVar(&countFlag, "count", false, "Print counts of paths, operations, schemas, tags, and security schemes as key=value lines instead of converting")
+	flag.BoolVar(&dryRunFlag, "dry-run", false, "Convert the spec and report the files that would be written, without writing them")
+	flag.StringVar(&entryFlag, "entry", "", "Path of the root spec inside a .zip input (defaults to openapi.yaml, swagger.json, ...)")
+	flag.BoolVar(&noSchemas, "no-schemas", false, "Omit the Schemas section")
+	flag.BoolVar(&reuseParams, "reusable-parameters", false, "Add a Reusable Parameters section for components.parameters (OpenAPI 3)")
+	flag.BoolVar(&reuseBodies, "reusable-request-bodies", false, "Add a Reusable Request Bodies section for components.requestBodies (OpenAPI 3)")
+	flag.BoolVar(&crlfFlag, "crlf", false, "Write CRLF line endings instead of LF")
+	flag.BoolVar(&verboseFlag, "verbose", false, "Print phase timings and input/output sizes to stderr")
+	flag.StringVar(&methodsFlag, "methods", "", "Comma-separated HTTP methods to render, e.g. get,head (default all)")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "Write a CPU profile of the conversion to this file")
+	flag.StringVar(&memProfile, "memprofile", "", "Write a heap profile taken after the conversion to this file")
+	flag.DurationVar(&timeoutFlag, "timeout", 0, "Abort the conversion if it takes longer than this, e.g. 30s (default no timeout)")
+	flag.StringVar(&outputFlag, "output-format", "markdown", "Comma-separated output formats; several formats require --out and write <out base>.<ext> per format")
 	flag.Parse()
 
 	inputsSet := 0
@@ -31,13 +81,53 @@ func main() {
 	if urlFlag != "" {
 		inputsSet++
 	}
+	if dirFlag != "" {
+		inputsSet++
+	}
 	if inputsSet != 1 {
-		fmt.Fprintln(os.Stderr, "exactly one of --file or --url must be specified")
+		fmt.Fprintln(os.Stderr, "exactly one of --file, --url, or --dir must be specified")
 		os.Exit(1)
 	}
+	if (dirFlag != "") != (outDirFlag != "") {
+		fmt.Fprintln(os.Stderr, "--dir and --out-dir must be used together")
+		os.Exit(1)
+	}
+	if dirFlag != "" && (outFlag != "" || checkFlag || countFlag) {
+		fmt.Fprintln(os.Stderr, "--dir cannot be combined with --out, --check, or --count")
+		os.Exit(1)
+	}
+	if checkFlag && outFlag == "" {
+		fmt.Fprintln(os.Stderr, "--check requires --out")
+		os.Exit(1)
+	}
+	formats, err := parseOutputFormatFlag(outputFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	urlFlag, err = parseURLFlag(urlFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if len(formats) > 1 && outFlag == "" && dirFlag == "" {
+		fmt.Fprintln(os.Stderr, "--out is required with more than one --output-format")
+		os.Exit(1)
+	}
+
+	// --timeout bounds the whole run: fetching --url as well as converting.
+	if timeoutFlag < 0 {
+		fmt.Fprintln(os.Stderr, "invalid --timeout value, must be >= 0")
+		os.Exit(1)
+	}
+	ctx := context.Background()
+	if timeoutFlag > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeoutFlag)
+		defer cancel()
+	}
 
 	var data []byte
-	var err error
 
 	if fileFlag != "" {
 		if fileFlag == "-" {
@@ -46,9 +136,9 @@ func main() {
 			data, err = os.ReadFile(fileFlag)
 		}
 	} else if urlFlag != "" {
-		resp, errReq := http.Get(urlFlag)
+		resp, errReq := fetchURL(ctx, urlFlag)
 		if errReq != nil {
-			fmt.Fprintf(os.Stderr, "failed to fetch URL: %v\n", errReq)
+			fmt.Fprintln(os.Stderr, fetchError(errReq, timeoutFlag))
 			os.Exit(1)
 		}
 		defer resp.Body.Close()
@@ -57,6 +147,10 @@ func main() {
 			os.Exit(1)
 		}
 		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fetchError(err, timeoutFlag))
+			os.Exit(1)
+		}
 	}
 
 	if err != nil {
@@ -71,23 +165,331 @@ func main() {
 		os.Exit(1)
 	}
 	opts.Format = parsedFormat
-
-	md, err := markdown.ToMarkdown(data, opts)
+	opts.InputEncoding = encodingFlag
+	if docFlag < 0 {
+		fmt.Fprintln(os.Stderr, "invalid --document value, must be >= 0")
+		os.Exit(1)
+	}
+	opts.DocumentIndex = docFlag
+	opts.SkipValidation, opts.StrictValidation, err = parseValidateFlag(validateFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to convert spec to markdown: %v\n", err)
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if widthFlag < 0 {
+		fmt.Fprintln(os.Stderr, "invalid --summary-width value, must be >= 0")
+		os.Exit(1)
+	}
+	opts.WrapWidth = widthFlag
+	if indentFlag < 1 {
+		fmt.Fprintln(os.Stderr, "invalid --indent value, must be >= 1")
 		os.Exit(1)
 	}
+	opts.Indent = indentFlag
+	opts.OmitSchemas = noSchemas
+	opts.ReusableParameters = reuseParams
+	opts.ReusableRequestBodies = reuseBodies
+	if crlfFlag {
+		opts.LineEnding = markdown.LineEndingCRLF
+	}
+	opts.Methods, err = parseMethodsFlag(methodsFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if verboseFlag {
+		opts.OnPhase = func(phase string, d time.Duration) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", phase, d)
+		}
+	}
+	if fileFlag != "" && fileFlag != "-" {
+		// A root-level $ref is resolved relative to the input file.
+		opts.BaseDir = filepath.Dir(fileFlag)
+	}
+
+	if dirFlag != "" {
+		files, skipped, err := convertDir(ctx, dirFlag, outDirFlag, opts, formats, dryRunFlag)
+		for _, rel := range skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s: not an OpenAPI or Swagger document\n", rel)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, conversionError(err, timeoutFlag))
+			os.Exit(1)
+		}
+		for _, f := range files {
+			switch {
+			case dryRunFlag:
+				fmt.Printf("%s\t%d bytes\n", f.path, len(f.content))
+			case verboseFlag:
+				fmt.Fprintf(os.Stderr, "wrote: %s\n", f.path)
+			}
+		}
+		return
+	}
+
+	if countFlag {
+		var sum markdown.Summary
+		if isZipArchive(fileFlag+urlFlag, data) {
+			sum, err = summarizeArchive(ctx, data, entryFlag, opts)
+		} else {
+			sum, err = markdown.SummarizeContext(ctx, data, opts)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Fprintln(os.Stderr, conversionError(err, timeoutFlag))
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse spec: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(formatSummary(sum))
+		return
+	}
 
-	if outFlag == "" {
-		_, _ = os.Stdout.Write([]byte(md))
+	stopProfiles, err := startProfiles(cpuProfile, memProfile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	start := time.Now()
+	var rendered map[markdown.OutputFormat]string
+	if isZipArchive(fileFlag+urlFlag, data) {
+		rendered, err = convertArchive(ctx, data, entryFlag, opts, formats)
 	} else {
-		if err := os.WriteFile(outFlag, []byte(md), 0o644); err != nil {
+		rendered, err = markdown.ConvertAllContext(ctx, data, opts, formats)
+	}
+	// Stop before any exit: os.Exit skips deferred calls, and a profile of
+	// a failing conversion is as useful as one of a successful run.
+	profileErr := stopProfiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, conversionError(err, timeoutFlag))
+		if profileErr != nil {
+			fmt.Fprintln(os.Stderr, profileErr.Error())
+		}
+		os.Exit(1)
+	}
+	if profileErr != nil {
+		fmt.Fprintln(os.Stderr, profileErr.Error())
+		os.Exit(1)
+	}
+	outputs := outputFiles(outFlag, rendered)
+	if verboseFlag {
+		fmt.Fprintf(os.Stderr, "total: %s\ninput: %d bytes\n", time.Since(start), len(data))
+		for _, o := range outputs {
+			fmt.Fprintf(os.Stderr, "output: %s %d bytes\n", nonEmptyPath(o.path), len(o.content))
+		}
+	}
+
+	if dryRunFlag {
+		// Report what would be written; the exit status only reflects whether
+		// conversion succeeded.
+		for _, o := range outputs {
+			fmt.Printf("%s\t%d bytes\n", nonEmptyPath(o.path), len(o.content))
+		}
+		return
+	}
+
+	if checkFlag {
+		stale := false
+		for _, o := range outputs {
+			existing, err := os.ReadFile(o.path)
+			if err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "failed to read output file: %v\n", err)
+				os.Exit(1)
+			}
+			if !markdown.Matches(string(existing), o.content) {
+				_, _ = os.Stdout.Write([]byte(markdown.UnifiedDiff(o.path, o.path+" (generated)", string(existing), o.content)))
+				fmt.Fprintf(os.Stderr, "%s is out of date\n", o.path)
+				stale = true
+			}
+		}
+		if stale {
+			os.Exit(1)
+		}
+		return
+	}
+
+	for _, o := range outputs {
+		if o.path == "" {
+			_, _ = os.Stdout.Write([]byte(o.content))
+			continue
+		}
+		if err := os.WriteFile(o.path, []byte(o.content), 0o644); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to write output file: %v\n", err)
 			os.Exit(1)
 		}
 	}
 }
 
+// conversionError is the message printed when a conversion fails, naming the
+// --timeout limit when the conversion ran out of time.
+func conversionError(err error, timeout time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("conversion timed out after %s (--timeout)", timeout)
+	}
+	return fmt.Sprintf("failed to convert spec to markdown: %v", err)
+}
+
+// fetchURL sends a GET request for url bounded by ctx.
+func fetchURL(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// fetchError is the message printed when fetching --url fails, naming the
+// --timeout limit when the fetch ran out of time.
+func fetchError(err error, timeout time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("fetching URL timed out after %s (--timeout)", timeout)
+	}
+	return fmt.Sprintf("failed to fetch URL: %v", err)
+}
+
+// startProfiles starts a pprof CPU profile written to cpuPath, when set, and
+// returns a function that stops it and, when memPath is set, writes a heap
+// profile there. Either path may be empty.
+func startProfiles(cpuPath, memPath string) (stop func() error, err error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		cpuFile, err = os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("failed to write CPU profile: %w", err)
+			}
+		}
+		if memPath == "" {
+			return nil
+		}
+		f, err := os.Create(memPath)
+		if err != nil {
+			return fmt.Errorf("failed to create memory profile: %w", err)
+		}
+		// Collect garbage first so the profile reflects live allocations.
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write memory profile: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write memory profile: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// outputFile is one rendering and the path it is written to ("" for stdout).
+type outputFile struct {
+	path    string
+	content string
+}
+
+// outputFiles lists the destinations markdown.OutputFiles gives rendered, in
+// path order, so dry runs, --verbose, --check and the real write all agree.
+func outputFiles(out string, rendered map[markdown.OutputFormat]string) []outputFile {
+	planned := markdown.OutputFiles(out, rendered)
+	files := make([]outputFile, 0, len(planned))
+	for path, content := range planned {
+		files = append(files, outputFile{path: path, content: content})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
+
+// outputFormats are the values --output-format accepts.
+var outputFormats = map[markdown.OutputFormat]bool{
+	markdown.OutputMarkdown: true,
+	markdown.OutputJSONL:    true,
+}
+
+// parseOutputFormatFlag splits a user-supplied --output-format list into
+// output formats, returning an error for unsupported or repeated values.
+func parseOutputFormatFlag(outputFlag string) ([]markdown.OutputFormat, error) {
+	var formats []markdown.OutputFormat
+	seen := map[markdown.OutputFormat]bool{}
+	for _, f := range strings.Split(outputFlag, ",") {
+		format := markdown.OutputFormat(strings.ToLower(strings.TrimSpace(f)))
+		if !outputFormats[format] {
+			return nil, fmt.Errorf("invalid --output-format value %q, must be a comma-separated list of: markdown, jsonl", f)
+		}
+		if !seen[format] {
+			seen[format] = true
+			formats = append(formats, format)
+		}
+	}
+	return formats, nil
+}
+
+// nonEmptyPath names the output destination for reports, using "(stdout)"
+// when no --out path is set.
+func nonEmptyPath(out string) string {
+	if out == "" {
+		return "(stdout)"
+	}
+	return out
+}
+
+// formatSummary renders --count output: one key=value line per count, in a
+// fixed order.
+func formatSummary(s markdown.Summary) string {
+	return fmt.Sprintf("paths=%d\noperations=%d\nschemas=%d\ntags=%d\nsecurity_schemes=%d\n",
+		s.Paths, s.Operations, s.Schemas, s.Tags, s.SecuritySchemes)
+}
+
+// parseURLFlag expands the github:owner/repo@ref:path shorthand accepted by
+// --url into the file's raw.githubusercontent.com URL. Other values are
+// returned unchanged.
+func parseURLFlag(urlFlag string) (string, error) {
+	rest, ok := strings.CutPrefix(urlFlag, "github:")
+	if !ok {
+		return urlFlag, nil
+	}
+	malformed := fmt.Errorf("invalid --url value %q, must be github:owner/repo@ref:path/to/openapi.yaml", urlFlag)
+	repo, rest, ok := strings.Cut(rest, "@")
+	if !ok {
+		return "", malformed
+	}
+	ref, path, ok := strings.Cut(rest, ":")
+	path = strings.TrimPrefix(path, "/")
+	owner, name, _ := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") || ref == "" || path == "" {
+		return "", malformed
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, name, ref, path), nil
+}
+
+// parseMethodsFlag splits a user-supplied --methods list into upper-case HTTP
+// methods, returning nil (all methods) for an empty value and an error for
+// unknown methods.
+func parseMethodsFlag(methodsFlag string) ([]string, error) {
+	if strings.TrimSpace(methodsFlag) == "" {
+		return nil, nil
+	}
+	var methods []string
+	for _, m := range strings.Split(methodsFlag, ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		switch m {
+		case "GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "HEAD", "TRACE":
+			methods = append(methods, m)
+		default:
+			return nil, fmt.Errorf("invalid --methods value %q, must be a comma-separated list of: get,post,put,delete,patch,options,head,trace", m)
+		}
+	}
+	return methods, nil
+}
+
 // parseFormatFlag maps a user-supplied --format string to a markdown.InputFormat,
 // returning an error for unsupported values.
 func parseFormatFlag(formatFlag string) (markdown.InputFormat, error) {
@@ -102,3 +504,19 @@ func parseFormatFlag(formatFlag string) (markdown.InputFormat, error) {
 		return "", fmt.Errorf("invalid --format value, must be one of: auto,json,yaml")
 	}
 }
+
+// parseValidateFlag maps a user-supplied --validate string to the
+// SkipValidation and StrictValidation options, returning an error for
+// unsupported values.
+func parseValidateFlag(validateFlag string) (skip, strict bool, err error) {
+	switch validateFlag {
+	case "auto", "":
+		return false, false, nil
+	case "off":
+		return true, false, nil
+	case "strict":
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf("invalid --validate value, must be one of: auto,off,strict")
+	}
+}
@@ -1,6 +1,18 @@
 package main
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmoose/openApiGo/pkg/markdown"
+)
 
 func TestParseFormatFlag_Valid(t *testing.T) {
 	cases := []struct {
@@ -32,3 +44,188 @@ func TestParseFormatFlag_Invalid(t *testing.T) {
 		t.Fatalf("expected error for invalid format, got nil")
 	}
 }
+
+func TestParseValidateFlag(t *testing.T) {
+	cases := []struct {
+		input      string
+		wantSkip   bool
+		wantStrict bool
+	}{
+		{"auto", false, false},
+		{"", false, false},
+		{"off", true, false},
+		{"strict", false, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			skip, strict, err := parseValidateFlag(tc.input)
+			if err != nil {
+				t.Fatalf("parseValidateFlag(%q) returned error: %v", tc.input, err)
+			}
+			if skip != tc.wantSkip || strict != tc.wantStrict {
+				t.Fatalf("parseValidateFlag(%q) = (%v, %v), want (%v, %v)", tc.input, skip, strict, tc.wantSkip, tc.wantStrict)
+			}
+		})
+	}
+
+	if _, _, err := parseValidateFlag("bogus"); err == nil {
+		t.Fatalf("expected error for invalid validate mode, got nil")
+	}
+}
+
+func TestNonEmptyPath(t *testing.T) {
+	if got := nonEmptyPath(""); got != "(stdout)" {
+		t.Fatalf("nonEmptyPath(\"\") = %q, want %q", got, "(stdout)")
+	}
+	if got := nonEmptyPath("api.md"); got != "api.md" {
+		t.Fatalf("nonEmptyPath(\"api.md\") = %q, want %q", got, "api.md")
+	}
+}
+
+func TestParseMethodsFlag(t *testing.T) {
+	got, err := parseMethodsFlag(" get, HEAD ")
+	if err != nil {
+		t.Fatalf("parseMethodsFlag returned error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "GET" || got[1] != "HEAD" {
+		t.Fatalf("parseMethodsFlag(\" get, HEAD \") = %v, want [GET HEAD]", got)
+	}
+	if got, err := parseMethodsFlag(""); err != nil || got != nil {
+		t.Fatalf("parseMethodsFlag(\"\") = (%v, %v), want (nil, nil)", got, err)
+	}
+	if _, err := parseMethodsFlag("get,fetch"); err == nil {
+		t.Fatalf("expected error for unknown method, got nil")
+	}
+}
+
+func TestParseOutputFormatFlag(t *testing.T) {
+	got, err := parseOutputFormatFlag(" Markdown,markdown ")
+	if err != nil {
+		t.Fatalf("parseOutputFormatFlag returned error: %v", err)
+	}
+	if len(got) != 1 || got[0] != markdown.OutputMarkdown {
+		t.Fatalf("parseOutputFormatFlag(\" Markdown,markdown \") = %v, want [markdown]", got)
+	}
+	if _, err := parseOutputFormatFlag("markdown,pdf"); err == nil {
+		t.Fatalf("expected error for unknown output format, got nil")
+	}
+}
+
+func TestOutputFiles(t *testing.T) {
+	got := outputFiles("docs/api.md", map[markdown.OutputFormat]string{markdown.OutputMarkdown: "# md"})
+	if len(got) != 1 || got[0].path != "docs/api.md" || got[0].content != "# md" {
+		t.Fatalf("single format outputFiles = %+v", got)
+	}
+	rendered := map[markdown.OutputFormat]string{markdown.OutputMarkdown: "# md", markdown.OutputJSONL: "{}\n"}
+	got = outputFiles("docs/api.md", rendered)
+	if len(got) != 2 || got[0].path != "docs/api.jsonl" || got[0].content != "{}\n" || got[1].path != "docs/api.md" {
+		t.Fatalf("multi format outputFiles = %+v", got)
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	got := formatSummary(markdown.Summary{Paths: 3, Operations: 5, Schemas: 4, Tags: 2, SecuritySchemes: 1})
+	want := "paths=3\noperations=5\nschemas=4\ntags=2\nsecurity_schemes=1\n"
+	if got != want {
+		t.Fatalf("formatSummary = %q, want %q", got, want)
+	}
+}
+
+func TestStartProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cpu := filepath.Join(dir, "cpu.pprof")
+	mem := filepath.Join(dir, "mem.pprof")
+	stop, err := startProfiles(cpu, mem)
+	if err != nil {
+		t.Fatalf("startProfiles returned error: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("stop returned error: %v", err)
+	}
+	for _, path := range []string{cpu, mem} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected profile %s: %v", path, err)
+		}
+		if info.Size() == 0 {
+			t.Fatalf("expected non-empty profile %s", path)
+		}
+	}
+
+	// No paths, no profiles.
+	stop, err = startProfiles("", "")
+	if err != nil {
+		t.Fatalf("startProfiles returned error: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("stop returned error: %v", err)
+	}
+
+	if _, err := startProfiles(filepath.Join(dir, "missing", "cpu.pprof"), ""); err == nil {
+		t.Fatal("expected an error for an uncreatable CPU profile path")
+	}
+}
+
+func TestParseURLFlag(t *testing.T) {
+	valid := map[string]string{
+		"github:acme/petstore@main:api/openapi.yaml":    "https://raw.githubusercontent.com/acme/petstore/main/api/openapi.yaml",
+		"github:acme/petstore@v1.2.0:/openapi.json":     "https://raw.githubusercontent.com/acme/petstore/v1.2.0/openapi.json",
+		"github:acme/petstore@feature/x:specs/api.yaml": "https://raw.githubusercontent.com/acme/petstore/feature/x/specs/api.yaml",
+		"https://example.com/openapi.yaml":              "https://example.com/openapi.yaml",
+	}
+	for in, want := range valid {
+		got, err := parseURLFlag(in)
+		if err != nil || got != want {
+			t.Fatalf("parseURLFlag(%q) = (%q, %v), want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{
+		"github:acme/petstore",
+		"github:acme/petstore@main",
+		"github:acme@main:openapi.yaml",
+		"github:acme/petstore/extra@main:openapi.yaml",
+		"github:acme/petstore@:openapi.yaml",
+		"github:acme/petstore@main:",
+	} {
+		if _, err := parseURLFlag(in); err == nil || !strings.Contains(err.Error(), "github:owner/repo@ref:path") {
+			t.Fatalf("expected a format error for %q, got %v", in, err)
+		}
+	}
+}
+
+func TestConversionError(t *testing.T) {
+	// A tiny deadline against a large spec: the conversion cannot finish.
+	var paths []string
+	for i := 0; i < 2000; i++ {
+		paths = append(paths, fmt.Sprintf(`"/items/%d": {"get": {"responses": {"200": {"description": "OK"}}}}`, i))
+	}
+	data := []byte(`{"openapi": "3.0.3", "info": {"title": "Large API", "version": "1.0.0"}, "paths": {` + strings.Join(paths, ",") + `}}`)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	_, err := markdown.ConvertAllContext(ctx, data, markdown.Options{}, []markdown.OutputFormat{markdown.OutputMarkdown})
+	if got, want := conversionError(err, time.Nanosecond), "conversion timed out after 1ns (--timeout)"; got != want {
+		t.Fatalf("conversionError = %q, want %q", got, want)
+	}
+
+	if got := conversionError(fmt.Errorf("bad spec"), 0); got != "failed to convert spec to markdown: bad spec" {
+		t.Fatalf("unexpected message for a non-timeout error: %q", got)
+	}
+}
+
+func TestFetchURLTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := fetchURL(ctx, srv.URL)
+	if got, want := fetchError(err, 10*time.Millisecond), "fetching URL timed out after 10ms (--timeout)"; got != want {
+		t.Fatalf("fetchError = %q, want %q", got, want)
+	}
+	if got := fetchError(fmt.Errorf("connection refused"), 0); got != "failed to fetch URL: connection refused" {
+		t.Fatalf("unexpected message for a non-timeout error: %q", got)
+	}
+}
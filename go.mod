@@ -5,6 +5,7 @@ go 1.25.1
 require (
 	github.com/getkin/kin-openapi v0.133.0
 	github.com/go-openapi/spec v0.22.1
+	golang.org/x/text v0.41.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -0,0 +1,135 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// largeSpec builds a synthetic document with n paths, each carrying a GET and a
+// POST with parameters, a request body, and a response, plus one schema per path.
+// The swagger flag selects a Swagger 2.0 document instead of OpenAPI 3.x.
+func largeSpec(n int, swagger bool) []byte {
+	paths := map[string]any{}
+	schemas := map[string]any{}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Item%d", i)
+		ref := "#/components/schemas/" + name
+		if swagger {
+			ref = "#/definitions/" + name
+		}
+		schemas[name] = map[string]any{
+			"type":     "object",
+			"required": []string{"id"},
+			"properties": map[string]any{
+				"id":     map[string]any{"type": "string", "description": "Identifier."},
+				"count":  map[string]any{"type": "integer", "default": 1},
+				"status": map[string]any{"type": "string", "enum": []string{"a", "b", "c"}},
+			},
+		}
+		var get, post map[string]any
+		if swagger {
+			get = map[string]any{
+				"tags": []string{fmt.Sprintf("tag%d", i%10)},
+				"parameters": []any{
+					map[string]any{"name": "limit", "in": "query", "type": "integer", "default": 20, "description": "Page size."},
+				},
+				"responses": map[string]any{
+					"200": map[string]any{"description": "ok", "schema": map[string]any{"$ref": ref}},
+				},
+			}
+			post = map[string]any{
+				"tags": []string{fmt.Sprintf("tag%d", i%10)},
+				"parameters": []any{
+					map[string]any{"name": "body", "in": "body", "required": true, "schema": map[string]any{"$ref": ref}},
+				},
+				"responses": map[string]any{
+					"201": map[string]any{"description": "created", "schema": map[string]any{"$ref": ref}},
+				},
+			}
+		} else {
+			content := map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": ref}}}
+			get = map[string]any{
+				"tags": []string{fmt.Sprintf("tag%d", i%10)},
+				"parameters": []any{
+					map[string]any{"name": "limit", "in": "query", "description": "Page size.", "schema": map[string]any{"type": "integer", "default": 20}},
+				},
+				"responses": map[string]any{
+					"200": map[string]any{"description": "ok", "content": content},
+				},
+			}
+			post = map[string]any{
+				"tags":        []string{fmt.Sprintf("tag%d", i%10)},
+				"requestBody": map[string]any{"content": content},
+				"responses": map[string]any{
+					"201": map[string]any{"description": "created", "content": content},
+				},
+			}
+		}
+		paths[fmt.Sprintf("/items%d/{id}", i)] = map[string]any{"get": get, "post": post}
+	}
+
+	doc := map[string]any{
+		"info":  map[string]any{"title": "Large API", "version": "1.0.0"},
+		"paths": paths,
+	}
+	if swagger {
+		doc["swagger"] = "2.0"
+		doc["definitions"] = schemas
+	} else {
+		doc["openapi"] = "3.0.3"
+		doc["components"] = map[string]any{"schemas": schemas}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func BenchmarkToMarkdown_Swagger2Large(b *testing.B) {
+	data := largeSpec(500, true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ToMarkdown(data, Options{Format: FormatJSON}); err != nil {
+			b.Fatalf("ToMarkdown returned error: %v", err)
+		}
+	}
+}
+
+func BenchmarkToMarkdown_OpenAPI3Large(b *testing.B) {
+	data := largeSpec(500, false)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ToMarkdown(data, Options{Format: FormatJSON, SkipValidation: true}); err != nil {
+			b.Fatalf("ToMarkdown returned error: %v", err)
+		}
+	}
+}
+
+// The 2,000-path benchmarks parse once and time rendering alone, which is
+// where per-path passes over the document would show up.
+
+func BenchmarkRender_Swagger2Paths2000(b *testing.B) {
+	benchmarkRender(b, largeSpec(2000, true), Options{Format: FormatJSON})
+}
+
+func BenchmarkRender_OpenAPI3Paths2000(b *testing.B) {
+	benchmarkRender(b, largeSpec(2000, false), Options{Format: FormatJSON, SkipValidation: true})
+}
+
+func benchmarkRender(b *testing.B, data []byte, opts Options) {
+	parsed, err := parseJSON(data, opts)
+	if err != nil {
+		b.Fatalf("parseJSON returned error: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parsed.render(markdownRenderer{}, opts); err != nil {
+			b.Fatalf("render returned error: %v", err)
+		}
+	}
+}
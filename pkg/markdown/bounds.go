@@ -0,0 +1,147 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// OpenAPI 3.1 (JSON Schema 2020-12) spells exclusive bounds as numbers
+// ("exclusiveMinimum": 0) where 3.0 uses a boolean flag on minimum/maximum.
+// kin-openapi only models the boolean form and rejects the numeric one, so
+// numeric bounds in 3.1 schemas are rewritten to the 3.0 form before
+// loading.
+
+// normalizeExclusiveBounds rewrites numeric exclusiveMinimum/exclusiveMaximum
+// keywords in the schemas of an OpenAPI 3.1 document to minimum/maximum plus
+// a boolean flag. When a schema also sets a stricter inclusive bound, that
+// bound is kept and the exclusive one dropped. Only schema objects are
+// rewritten, not example payloads or defaults that happen to use the same
+// keys. Other documents, and those without numeric exclusive bounds, are
+// returned unchanged.
+func normalizeExclusiveBounds(data []byte) []byte {
+	if !bytes.Contains(data, []byte(`"exclusiveMinimum"`)) && !bytes.Contains(data, []byte(`"exclusiveMaximum"`)) {
+		return data
+	}
+	// Numbers stay json.Number so integers beyond float64 precision, in
+	// bounds and in the rest of the document, survive the round trip.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if dec.Decode(&doc) != nil {
+		return data
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return data
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.1") {
+		return data
+	}
+	if !rewriteDocumentBounds(doc) {
+		return data
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// rewriteDocumentBounds finds the schemas under v, a part of the document
+// outside any schema, and rewrites their bounds: the values of "schema" keys
+// (parameters, headers, media types) and of "schemas" maps (components).
+// Examples and extensions are skipped. It reports whether anything changed.
+func rewriteDocumentBounds(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			switch {
+			case key == "schema":
+				changed = rewriteSchemaBounds(child) || changed
+			case key == "schemas":
+				if m, ok := child.(map[string]any); ok {
+					for _, s := range m {
+						changed = rewriteSchemaBounds(s) || changed
+					}
+				}
+			case key == "example" || key == "examples" || strings.HasPrefix(key, "x-"):
+			default:
+				changed = rewriteDocumentBounds(child) || changed
+			}
+		}
+	case []any:
+		for _, child := range v {
+			changed = rewriteDocumentBounds(child) || changed
+		}
+	}
+	return changed
+}
+
+// Subschema keywords of JSON Schema 2020-12, by the shape of their value.
+var (
+	schemaKeywords     = []string{"items", "additionalProperties", "not", "contains", "if", "then", "else", "propertyNames", "unevaluatedItems", "unevaluatedProperties", "contentSchema"}
+	schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	schemaMapKeywords  = []string{"properties", "patternProperties", "$defs", "dependentSchemas"}
+)
+
+// rewriteSchemaBounds rewrites the bounds of schema v and its subschemas,
+// leaving example, default, const, and enum values alone, and reports
+// whether anything changed.
+func rewriteSchemaBounds(v any) bool {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	changed := rewriteBound(obj, "exclusiveMinimum", "minimum", func(cmp int) bool { return cmp >= 0 })
+	changed = rewriteBound(obj, "exclusiveMaximum", "maximum", func(cmp int) bool { return cmp <= 0 }) || changed
+	for _, key := range schemaKeywords {
+		changed = rewriteSchemaBounds(obj[key]) || changed
+	}
+	for _, key := range schemaListKeywords {
+		list, _ := obj[key].([]any)
+		for _, s := range list {
+			changed = rewriteSchemaBounds(s) || changed
+		}
+	}
+	for _, key := range schemaMapKeywords {
+		m, _ := obj[key].(map[string]any)
+		for _, s := range m {
+			changed = rewriteSchemaBounds(s) || changed
+		}
+	}
+	return changed
+}
+
+// rewriteBound converts one numeric exclusive keyword of obj. stricter reports,
+// given the exclusive value compared with the inclusive one, whether the
+// exclusive value is at least as tight.
+func rewriteBound(obj map[string]any, exclusiveKey, inclusiveKey string, stricter func(cmp int) bool) bool {
+	ex, ok := obj[exclusiveKey].(json.Number)
+	if !ok {
+		return false
+	}
+	if in, ok := obj[inclusiveKey].(json.Number); ok {
+		if cmp, ok := compareNumbers(ex, in); ok && !stricter(cmp) {
+			delete(obj, exclusiveKey)
+			return true
+		}
+	}
+	obj[inclusiveKey] = ex
+	obj[exclusiveKey] = true
+	return true
+}
+
+// compareNumbers compares two JSON numbers exactly, returning -1, 0 or +1 as
+// a is less than, equal to or greater than b. ok is false if either does not
+// parse.
+func compareNumbers(a, b json.Number) (cmp int, ok bool) {
+	x, okA := new(big.Rat).SetString(a.String())
+	y, okB := new(big.Rat).SetString(b.String())
+	if !okA || !okB {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
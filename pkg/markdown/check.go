@@ -0,0 +1,194 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Output verification for "docs are up to date" gates: compare a fresh
+// rendering with a previously written file and describe any drift.
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffCellLimit caps the LCS table size; beyond it the differing middle of
+// the two inputs is reported as one replaced block.
+const diffCellLimit = 4 << 20
+
+// Matches reports whether a previously written rendering and a fresh one are
+// identical, ignoring CRLF vs LF line endings.
+func Matches(existing, rendered string) bool {
+	return normalizeNewlines(existing) == normalizeNewlines(rendered)
+}
+
+// UnifiedDiff returns a unified diff turning a into b, labelled with the given
+// names, or "" when the two (after line-ending normalization) are identical.
+func UnifiedDiff(aName, bName, a, b string) string {
+	a, b = normalizeNewlines(a), normalizeNewlines(b)
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(ops); {
+		// Find the next change and open a hunk with leading context.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		lo := max(start-diffContext, 0)
+
+		// Extend the hunk until a run of unchanged lines is long enough to
+		// separate it from the next change.
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				break
+			}
+			end = run
+		}
+		hi := min(end+diffContext, len(ops))
+
+		aStart, bStart := ops[lo].aLine, ops[lo].bLine
+		var aCount, bCount int
+		for _, op := range ops[lo:hi] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range ops[lo:hi] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		start = hi
+	}
+	return out.String()
+}
+
+// diffOp is one line of an edit script: ' ' (kept), '-' (removed from a), or
+// '+' (added from b). aLine and bLine are the 1-based positions the line
+// would occupy in each input.
+type diffOp struct {
+	kind         byte
+	text         string
+	aLine, bLine int
+}
+
+// diffLines computes a line edit script from a to b. Common prefix and suffix
+// are trimmed first; the remainder uses a longest-common-subsequence table.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	am, bm := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	ai, bi := 1, 1
+	keep := func(s string) {
+		ops = append(ops, diffOp{' ', s, ai, bi})
+		ai++
+		bi++
+	}
+	del := func(s string) {
+		ops = append(ops, diffOp{'-', s, ai, bi})
+		ai++
+	}
+	add := func(s string) {
+		ops = append(ops, diffOp{'+', s, ai, bi})
+		bi++
+	}
+
+	for _, s := range a[:pre] {
+		keep(s)
+	}
+	if len(am)*len(bm) > diffCellLimit {
+		for _, s := range am {
+			del(s)
+		}
+		for _, s := range bm {
+			add(s)
+		}
+	} else {
+		// lcs[i][j] is the LCS length of am[i:] and bm[j:].
+		lcs := make([][]int, len(am)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(bm)+1)
+		}
+		for i := len(am) - 1; i >= 0; i-- {
+			for j := len(bm) - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(am) || j < len(bm) {
+			switch {
+			case i < len(am) && j < len(bm) && am[i] == bm[j]:
+				keep(am[i])
+				i++
+				j++
+			case j == len(bm) || (i < len(am) && lcs[i+1][j] >= lcs[i][j+1]):
+				del(am[i])
+				i++
+			default:
+				add(bm[j])
+				j++
+			}
+		}
+	}
+	for _, s := range a[len(a)-suf:] {
+		keep(s)
+	}
+	return ops
+}
+
+// hunkRange formats a unified diff range. An empty range points at the line
+// before the insertion point, as diff(1) does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits s on newlines. Text ending in a newline yields a final
+// empty element, so a missing trailing newline still shows up as a change.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
@@ -0,0 +1,240 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// Schema composition: flattening allOf members into a single property list,
+// and summarizing oneOf/anyOf alternatives.
+
+// mergedPropertiesOpenAPI3 returns the properties of s merged with those of its
+// allOf members (recursively), together with the union of their required
+// lists. Members are applied in order and s's own properties last, so a later
+// declaration of the same property wins.
+func mergedPropertiesOpenAPI3(s *openapi3.Schema) (openapi3.Schemas, []string) {
+	props := openapi3.Schemas{}
+	var required []string
+	mergeOpenAPI3(s, props, &required, map[*openapi3.Schema]bool{})
+	return props, required
+}
+
+func mergeOpenAPI3(s *openapi3.Schema, props openapi3.Schemas, required *[]string, seen map[*openapi3.Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+	for _, member := range s.AllOf {
+		if member != nil {
+			mergeOpenAPI3(member.Value, props, required, seen)
+		}
+	}
+	for pn, ps := range s.Properties {
+		props[pn] = ps
+	}
+	*required = appendMissing(*required, s.Required...)
+}
+
+// requiredRecapOpenAPI3 returns " (required: id, name)" for an object body
+// schema with required properties, including those of its allOf members;
+// otherwise "". kin-openapi has already resolved $ref, so named schemas are
+// recapped the same as inline ones.
+func requiredRecapOpenAPI3(ref *openapi3.SchemaRef) string {
+	if ref == nil || ref.Value == nil {
+		return ""
+	}
+	s := ref.Value
+	if len(s.Type.Slice()) > 0 && !s.Type.Includes("object") {
+		return ""
+	}
+	_, required := mergedPropertiesOpenAPI3(s)
+	if len(required) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (required: %s)", strings.Join(required, ", "))
+}
+
+// writeOnlyPropertiesOpenAPI3 returns the sorted names of an object body
+// schema's writeOnly properties, including those of its allOf members.
+func writeOnlyPropertiesOpenAPI3(ref *openapi3.SchemaRef) []string {
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	props, _ := mergedPropertiesOpenAPI3(ref.Value)
+	var names []string
+	for _, pn := range sortedKeys(props) {
+		if ps := props[pn]; ps != nil && ps.Value != nil && ps.Value.WriteOnly {
+			names = append(names, pn)
+		}
+	}
+	return names
+}
+
+// schemasDifferNote follows the Request Body heading when its media types
+// carry different schemas.
+const schemasDifferNote = "schemas differ by content type"
+
+// schemasDifferOpenAPI3 reports whether the media types of a body carry more
+// than one distinct schema, comparing their resolved JSON forms so a named
+// schema matches an identical inline one. Media types without a schema are
+// ignored.
+func schemasDifferOpenAPI3(content openapi3.Content) bool {
+	seen := map[string]bool{}
+	for _, media := range content {
+		if media == nil || media.Schema == nil || media.Schema.Value == nil {
+			continue
+		}
+		data, err := json.Marshal(media.Schema.Value)
+		if err != nil {
+			continue
+		}
+		seen[string(data)] = true
+	}
+	return len(seen) > 1
+}
+
+// byteFormatNoteOpenAPI3 is byteFormatNote for an inline OpenAPI 3 schema;
+// named schemas keep their ref.
+func byteFormatNoteOpenAPI3(ref *openapi3.SchemaRef, binaryNote string) string {
+	if ref == nil || ref.Value == nil || ref.Ref != "" {
+		return ""
+	}
+	return byteFormatNote(ref.Value.Type.Slice(), ref.Value.Format, binaryNote)
+}
+
+// schemaExampleOpenAPI3 returns a schema's example or, for an array without
+// one, a one-element array of its items' example, so the example keeps the
+// array's shape. It returns nil when there is neither.
+func schemaExampleOpenAPI3(s *openapi3.Schema) any {
+	if s.Example != nil {
+		return s.Example
+	}
+	if s.Type.Includes("array") && s.Items != nil && s.Items.Value != nil && s.Items.Value.Example != nil {
+		return []any{s.Items.Value.Example}
+	}
+	return nil
+}
+
+// mergedPropertiesSwagger2 is mergedPropertiesOpenAPI3 for Swagger 2.0, where
+// allOf members referencing definitions are resolved through defs.
+func mergedPropertiesSwagger2(s *spec.Schema, defs spec.Definitions) (spec.SchemaProperties, []string) {
+	props := spec.SchemaProperties{}
+	var required []string
+	mergeSwagger2(s, defs, props, &required, map[string]bool{})
+	return props, required
+}
+
+func mergeSwagger2(s *spec.Schema, defs spec.Definitions, props spec.SchemaProperties, required *[]string, seen map[string]bool) {
+	if s == nil {
+		return
+	}
+	if ref := s.Ref.String(); ref != "" {
+		name := strings.TrimPrefix(ref, swagger2SchemaRefPrefix)
+		def, ok := defs[name]
+		if !ok || seen[name] {
+			return
+		}
+		seen[name] = true
+		mergeSwagger2(&def, defs, props, required, seen)
+		return
+	}
+	for i := range s.AllOf {
+		mergeSwagger2(&s.AllOf[i], defs, props, required, seen)
+	}
+	for pn, ps := range s.Properties {
+		props[pn] = ps
+	}
+	*required = appendMissing(*required, s.Required...)
+}
+
+// appendMissing appends the values not already present in list.
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// alternativesOpenAPI3 summarizes a oneOf/anyOf schema as
+// "oneOf(CreateUser, CreateBot)", naming referenced members through link and
+// inline ones by type. It returns "" when ref has neither keyword.
+func alternativesOpenAPI3(ref *openapi3.SchemaRef, link func(string) string) string {
+	if ref == nil || ref.Value == nil {
+		return ""
+	}
+	var groups []string
+	for _, g := range []struct {
+		keyword string
+		members openapi3.SchemaRefs
+	}{{"oneOf", ref.Value.OneOf}, {"anyOf", ref.Value.AnyOf}} {
+		if len(g.members) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(g.members))
+		for _, m := range g.members {
+			if name := refName(m.Ref); name != "" {
+				names = append(names, link(name))
+			} else {
+				names = append(names, typeOfSchemaRef(m))
+			}
+		}
+		groups = append(groups, fmt.Sprintf("%s(%s)", g.keyword, strings.Join(names, ", ")))
+	}
+	return strings.Join(groups, " ")
+}
+
+// bodyVariant is one member of a discriminated oneOf: the discriminator
+// value that selects it and its schema.
+type bodyVariant struct {
+	Value  string
+	Schema *openapi3.SchemaRef
+}
+
+// discriminatedVariantsOpenAPI3 lists the members of a oneOf schema that
+// has a discriminator, in oneOf order, with the discriminator property. A
+// member's value is its key in the discriminator mapping or, without one,
+// its schema name as the spec defines. It returns no variants when ref is
+// not a discriminated oneOf.
+func discriminatedVariantsOpenAPI3(ref *openapi3.SchemaRef) (string, []bodyVariant) {
+	if ref == nil || ref.Value == nil || ref.Value.Discriminator == nil || len(ref.Value.OneOf) == 0 {
+		return "", nil
+	}
+	d := ref.Value.Discriminator
+	variants := make([]bodyVariant, 0, len(ref.Value.OneOf))
+	for _, m := range ref.Value.OneOf {
+		value := refName(m.Ref)
+		for _, key := range sortedKeys(d.Mapping) {
+			if target := d.Mapping[key]; target == m.Ref || (m.Ref != "" && target == refName(m.Ref)) {
+				value = key
+				break
+			}
+		}
+		variants = append(variants, bodyVariant{Value: value, Schema: m})
+	}
+	return d.PropertyName, variants
+}
+
+// variantExampleOpenAPI3 picks the example shown for a variant: the first
+// named example (in sorted order) whose discriminator property selects it,
+// else the variant schema's own example. name is "" for a schema example.
+func variantExampleOpenAPI3(property string, v bodyVariant, examples openapi3.Examples) (name string, ex *openapi3.Example) {
+	for _, n := range sortedKeys(examples) {
+		e := examples[n]
+		if e == nil || e.Value == nil {
+			continue
+		}
+		if obj, ok := e.Value.Value.(map[string]any); ok && obj[property] == v.Value {
+			return n, e.Value
+		}
+	}
+	if v.Schema != nil && v.Schema.Value != nil && v.Schema.Value.Example != nil {
+		return "", &openapi3.Example{Value: v.Schema.Value.Example}
+	}
+	return "", nil
+}
@@ -0,0 +1,84 @@
+package markdown
+
+import (
+	"context"
+	"fmt"
+)
+
+// ToMarkdownContext is ToMarkdown bounded by ctx: when ctx is done before the
+// conversion finishes, it returns ctx.Err(). Rendering checks ctx between
+// operations and schemas and stops there; parsing a single document is not
+// interrupted.
+func ToMarkdownContext(ctx context.Context, data []byte, opts Options) (string, error) {
+	opts.ctx = ctx
+	return withContext(ctx, func() (string, error) {
+		return ToMarkdown(data, opts)
+	})
+}
+
+// ConvertAllContext is ConvertAll bounded by ctx, as ToMarkdownContext is
+// ToMarkdown.
+func ConvertAllContext(ctx context.Context, data []byte, opts Options, formats []OutputFormat) (map[OutputFormat]string, error) {
+	opts.ctx = ctx
+	return withContext(ctx, func() (map[OutputFormat]string, error) {
+		return ConvertAll(data, opts, formats)
+	})
+}
+
+// SummarizeContext is Summarize bounded by ctx, as ToMarkdownContext is
+// ToMarkdown.
+func SummarizeContext(ctx context.Context, data []byte, opts Options) (Summary, error) {
+	opts.ctx = ctx
+	return withContext(ctx, func() (Summary, error) {
+		return Summarize(data, opts)
+	})
+}
+
+// withContext runs fn in its own goroutine and returns its result, or
+// ctx.Err() if ctx is done first. An already-done ctx does not start fn. A
+// panic in fn is returned as an error rather than crashing the process,
+// since no caller is left on the goroutine's stack to recover it.
+func withContext[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("conversion panic: %v", r)}
+			}
+		}()
+		v, err := fn()
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// canceled returns the error of the context a *Context call runs under once
+// it is done, and nil otherwise or outside such a call.
+func (o Options) canceled() error {
+	if o.ctx == nil {
+		return nil
+	}
+	return o.ctx.Err()
+}
+
+// contextOrBackground returns the context a *Context call runs under, or
+// context.Background() outside such a call.
+func (o Options) contextOrBackground() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
@@ -0,0 +1,165 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// Model diagram: a Mermaid classDiagram of the named schemas, with their
+// properties as fields and property $refs as associations.
+
+// diagramClass is one named schema in the model diagram.
+type diagramClass struct {
+	Name   string
+	Fields []diagramField
+}
+
+// diagramField is one property of a diagramClass. Type is its Mermaid type
+// label; Ref names the schema the property refers to, directly or as array
+// items, or is empty.
+type diagramField struct {
+	Name string
+	Type string
+	Ref  string
+}
+
+// diagramClassesOpenAPI3 builds the diagram classes for components.schemas,
+// sorted by name, with allOf members' properties merged in.
+func diagramClassesOpenAPI3(schemas openapi3.Schemas) []diagramClass {
+	classes := make([]diagramClass, 0, len(schemas))
+	for _, name := range sortedKeys(schemas) {
+		c := diagramClass{Name: name}
+		if ref := schemas[name]; ref != nil && ref.Value != nil {
+			props, _ := mergedPropertiesOpenAPI3(ref.Value)
+			for _, pn := range sortedKeys(props) {
+				c.Fields = append(c.Fields, diagramFieldOpenAPI3(pn, props[pn]))
+			}
+		}
+		classes = append(classes, c)
+	}
+	return classes
+}
+
+func diagramFieldOpenAPI3(name string, ps *openapi3.SchemaRef) diagramField {
+	f := diagramField{Name: name, Type: "object"}
+	switch {
+	case ps == nil:
+	case ps.Ref != "":
+		f.Ref = refName(ps.Ref)
+		f.Type = diagramID(f.Ref)
+	case ps.Value == nil:
+	case ps.Value.Type.Is("array") && ps.Value.Items != nil:
+		f.Type = "object[]"
+		if items := ps.Value.Items; items.Ref != "" {
+			f.Ref = refName(items.Ref)
+			f.Type = diagramID(f.Ref) + "[]"
+		} else if items.Value != nil {
+			f.Type = diagramType(items.Value.Type.Slice()) + "[]"
+		}
+	default:
+		f.Type = diagramType(ps.Value.Type.Slice())
+	}
+	return f
+}
+
+// diagramClassesSwagger2 is diagramClassesOpenAPI3 for Swagger 2.0
+// definitions.
+func diagramClassesSwagger2(defs spec.Definitions) []diagramClass {
+	classes := make([]diagramClass, 0, len(defs))
+	for _, name := range sortedKeys(defs) {
+		sch := defs[name]
+		c := diagramClass{Name: name}
+		props, _ := mergedPropertiesSwagger2(&sch, defs)
+		for _, pn := range sortedKeys(props) {
+			ps := props[pn]
+			c.Fields = append(c.Fields, diagramFieldSwagger2(pn, &ps))
+		}
+		classes = append(classes, c)
+	}
+	return classes
+}
+
+func diagramFieldSwagger2(name string, ps *spec.Schema) diagramField {
+	f := diagramField{Name: name, Type: diagramType(ps.Type)}
+	switch {
+	case ps.Ref.String() != "":
+		f.Ref = refName(ps.Ref.String())
+		f.Type = diagramID(f.Ref)
+	case len(ps.Type) == 1 && ps.Type[0] == "array" && ps.Items != nil && ps.Items.Schema != nil:
+		items := ps.Items.Schema
+		if ref := items.Ref.String(); ref != "" {
+			f.Ref = refName(ref)
+			f.Type = diagramID(f.Ref) + "[]"
+		} else {
+			f.Type = diagramType(items.Type) + "[]"
+		}
+	}
+	return f
+}
+
+// diagramType returns the first non-null type of a schema, or "object".
+func diagramType(types []string) string {
+	for _, t := range types {
+		if t != "null" {
+			return t
+		}
+	}
+	return "object"
+}
+
+// diagramID makes a schema name usable as a Mermaid class name, which allows
+// only letters, digits, and underscores.
+func diagramID(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// writeModelDiagram emits a fenced Mermaid classDiagram of at most max
+// classes (DefaultModelDiagramMaxNodes when max is not positive), taken in
+// order, with a note when some were left out. Associations are drawn only
+// between classes in the diagram.
+func writeModelDiagram(b *bytes.Buffer, classes []diagramClass, max int) {
+	if len(classes) == 0 {
+		return
+	}
+	if max <= 0 {
+		max = DefaultModelDiagramMaxNodes
+	}
+	blankLine(b)
+	if len(classes) > max {
+		fmt.Fprintf(b, "Model diagram limited to the first %d of %d schemas.\n\n", max, len(classes))
+		classes = classes[:max]
+	}
+	shown := map[string]bool{}
+	for _, c := range classes {
+		shown[c.Name] = true
+	}
+	b.WriteString("```mermaid\nclassDiagram\n")
+	for _, c := range classes {
+		if len(c.Fields) == 0 {
+			fmt.Fprintf(b, "  class %s\n", diagramID(c.Name))
+			continue
+		}
+		fmt.Fprintf(b, "  class %s {\n", diagramID(c.Name))
+		for _, f := range c.Fields {
+			fmt.Fprintf(b, "    +%s %s\n", f.Type, f.Name)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, c := range classes {
+		for _, f := range c.Fields {
+			if f.Ref != "" && shown[f.Ref] {
+				fmt.Fprintf(b, "  %s --> %s : %s\n", diagramID(c.Name), diagramID(f.Ref), f.Name)
+			}
+		}
+	}
+	b.WriteString("```\n")
+}
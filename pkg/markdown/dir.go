@@ -0,0 +1,115 @@
+package markdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// specExtensions are the file extensions ConvertDir considers.
+var specExtensions = map[string]bool{".json": true, ".yaml": true, ".yml": true}
+
+// ConvertDir converts every .json, .yaml, and .yml spec under dir to each
+// format and returns the outputs keyed by slash-separated path relative to
+// dir, with the format's extension: dir/billing/invoices.yaml becomes
+// "billing/invoices.md". Nothing is written.
+//
+// Files that are not OpenAPI or Swagger documents, such as a package.json or
+// a CI config, are skipped and returned in skipped by their relative path. A
+// file is a spec when its top level has an "openapi", "swagger", or "$ref"
+// key; only files that decode cleanly can be told apart, so one that is not
+// valid JSON or YAML is an error rather than skipped. Each spec's root $ref
+// resolves against its own directory. Two specs that map to the same output,
+// such as a.json and a.yaml, are an error naming both. Conversion stops at
+// the first spec that fails or when ctx is done.
+func ConvertDir(ctx context.Context, dir string, opts Options, formats []OutputFormat) (outputs map[string]string, skipped []string, err error) {
+	var specs []string
+	err = filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && specExtensions[strings.ToLower(filepath.Ext(name))] {
+			specs = append(specs, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(specs)
+
+	outputs = map[string]string{}
+	sources := map[string]string{}
+	for _, p := range specs {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil, skipped, err
+		}
+		rel = filepath.ToSlash(rel)
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, skipped, err
+		}
+		isSpec, err := isSpecDocument(data, opts)
+		if err != nil {
+			return nil, skipped, fmt.Errorf("%s: %w", rel, err)
+		}
+		if !isSpec {
+			skipped = append(skipped, rel)
+			continue
+		}
+		base := strings.TrimSuffix(rel, path.Ext(rel))
+		for _, f := range formats {
+			name := base + f.Extension()
+			if other, ok := sources[name]; ok {
+				return nil, skipped, fmt.Errorf("%s and %s both convert to %s", other, rel, name)
+			}
+			sources[name] = rel
+		}
+		fopts := opts
+		fopts.BaseDir = filepath.Dir(p)
+		rendered, err := ConvertAllContext(ctx, data, fopts, formats)
+		if err != nil {
+			return nil, skipped, fmt.Errorf("%s: %w", rel, err)
+		}
+		for _, f := range formats {
+			outputs[base+f.Extension()] = rendered[f]
+		}
+	}
+	return outputs, skipped, nil
+}
+
+// isSpecDocument reports whether data decodes to an object with a top-level
+// "openapi", "swagger", or root "$ref" key. Only the top level is inspected;
+// nothing is parsed as a spec. Data that does not decode as JSON or YAML is
+// an error, since it may be a spec with a syntax error.
+func isSpecDocument(data []byte, opts Options) (bool, error) {
+	data, err := decodeInput(data, opts.InputEncoding)
+	if err != nil {
+		return false, err
+	}
+	jsonData, err := normalizeToJSON(data, opts.Format, opts.DocumentIndex)
+	if err != nil {
+		return false, err
+	}
+	var top any
+	if err := json.Unmarshal(jsonData, &top); err != nil {
+		return false, fmt.Errorf("failed to parse input as JSON: %w", err)
+	}
+	obj, ok := top.(map[string]any)
+	if !ok {
+		return false, nil
+	}
+	for _, key := range []string{"openapi", "swagger", "$ref"} {
+		if _, ok := obj[key]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,307 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// ElementRenderer renders a document one element at a time, for output
+// formats that do not want to walk either spec version themselves. Wrap it
+// with Elements to use it as Options.Renderer. Each hook writes its element
+// to w; an error stops the render. The built-in Markdown output is an
+// ElementRenderer driven by the same traversal.
+//
+// Both spec versions are traversed the same way: Overview once, then one
+// Section per operation tag in sorted order followed by that tag's
+// operations (an "Untagged" section last when some operations have no tags),
+// then, unless Options.OmitSchemas is set, a "Schemas" section followed by
+// each schema in name order. Sections are only visited when they have
+// elements. Operations honor Options.Methods, Options.SortOperationsBy,
+// Options.PrimaryTagOnly, and Options.ExcludeInternal as in the Markdown
+// output.
+type ElementRenderer interface {
+	Overview(w io.Writer, o OverviewElement) error
+	Section(w io.Writer, s SectionElement) error
+	Operation(w io.Writer, op OperationElement) error
+	Schema(w io.Writer, s SchemaElement) error
+}
+
+// OverviewElement is the document's info block.
+type OverviewElement struct {
+	Title       string
+	Version     string
+	SpecVersion string // the openapi or swagger field, such as "3.0.3"
+	Description string
+}
+
+// SectionKind says what a SectionElement groups.
+type SectionKind string
+
+const (
+	// SectionTag groups the operations of one tag, or the untagged ones.
+	SectionTag SectionKind = "tag"
+	// SectionSchemas groups the component schemas (Swagger 2.0 definitions).
+	SectionSchemas SectionKind = "schemas"
+)
+
+// SectionElement starts a group of operations or schemas.
+type SectionElement struct {
+	Kind        SectionKind
+	Name        string
+	Description string // the tag's description, when declared
+
+	// untagged marks the section of operations without tags, whatever
+	// its Name.
+	untagged bool
+	// operations are every operation carrying the section's tag, including
+	// those Options.PrimaryTagOnly renders under another tag.
+	operations []OperationElement
+}
+
+// OperationElement is one operation.
+type OperationElement struct {
+	Method      string
+	Path        string
+	OperationID string
+	Tags        []string
+	Summary     string
+	Description string
+	Deprecated  bool
+	// Anchor is the operation heading's anchor in the Markdown output,
+	// without "#".
+	Anchor string
+
+	// source is the operation as indexed, an openAPI3Op or a swagger2Op,
+	// for the built-in Markdown output.
+	source any
+}
+
+// SchemaElement is one component schema.
+type SchemaElement struct {
+	Name        string
+	Type        string
+	Description string
+	Properties  []string // property names in sorted order
+	Required    []string
+
+	// source is the schema, an *openapi3.SchemaRef or a spec.Schema, for the
+	// built-in Markdown output.
+	source any
+}
+
+// Elements returns a Renderer that drives er through a document.
+func Elements(er ElementRenderer) Renderer {
+	return elementsRenderer{er}
+}
+
+type elementsRenderer struct {
+	er ElementRenderer
+}
+
+func (r elementsRenderer) RenderOpenAPI3(doc *openapi3.T, raw []byte, opts Options) (string, error) {
+	if doc == nil {
+		return "", fmt.Errorf("render openapi 3: nil document")
+	}
+	defer phaseTimer(opts, PhaseRender)()
+	d, err := documentElementsOpenAPI3(doc, renderedComponentsOpenAPI3(doc, opts), opts)
+	if err != nil {
+		return "", err
+	}
+	return walkElements(d, r.er, opts)
+}
+
+func (r elementsRenderer) RenderSwagger2(doc *spec.Swagger, raw []byte, opts Options) (string, error) {
+	if doc == nil {
+		return "", fmt.Errorf("render swagger 2.0: nil document")
+	}
+	defer phaseTimer(opts, PhaseRender)()
+	d, err := documentElementsSwagger2(renderedSwagger2(doc, opts), opts)
+	if err != nil {
+		return "", err
+	}
+	return walkElements(d, r.er, opts)
+}
+
+// elementsFinisher is implemented by element renderers that write more after
+// the last element, as the built-in Markdown output does with its reference
+// sections and appendices.
+type elementsFinisher interface {
+	finish(b *bytes.Buffer) error
+}
+
+// documentElements is a document of either version reduced to the elements
+// an ElementRenderer sees.
+type documentElements struct {
+	overview   OverviewElement
+	tags       map[string]string // declared tag descriptions
+	operations []OperationElement
+	schemas    []SchemaElement
+}
+
+// walkElements is the traversal described on ElementRenderer, and the only
+// one: the built-in Markdown output is an ElementRenderer too.
+func walkElements(d documentElements, er ElementRenderer, opts Options) (string, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := er.Overview(b, d.overview); err != nil {
+		return "", err
+	}
+	names, tagged, untagged := groupByTag(d.operations, func(op OperationElement) []string { return op.Tags })
+	sections := make([]SectionElement, 0, len(names)+1)
+	for _, name := range names {
+		sections = append(sections, SectionElement{Kind: SectionTag, Name: name, Description: d.tags[name], operations: tagged[name]})
+	}
+	if len(untagged) > 0 {
+		sections = append(sections, SectionElement{Kind: SectionTag, Name: "Untagged", untagged: true, operations: untagged})
+	}
+	for _, s := range sections {
+		if err := er.Section(b, s); err != nil {
+			return "", err
+		}
+		for _, op := range s.operations {
+			if opts.PrimaryTagOnly && !s.untagged && op.Tags[0] != s.Name {
+				continue
+			}
+			if err := opts.canceled(); err != nil {
+				return "", err
+			}
+			if err := er.Operation(b, op); err != nil {
+				return "", err
+			}
+		}
+	}
+	if len(d.schemas) > 0 && !opts.OmitSchemas {
+		if err := er.Section(b, SectionElement{Kind: SectionSchemas, Name: "Schemas"}); err != nil {
+			return "", err
+		}
+		for _, s := range d.schemas {
+			if err := opts.canceled(); err != nil {
+				return "", err
+			}
+			if err := er.Schema(b, s); err != nil {
+				return "", err
+			}
+		}
+	}
+	if f, ok := er.(elementsFinisher); ok {
+		if err := f.finish(b); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// documentElementsOpenAPI3 reduces doc to its elements. components are the
+// ones rendered, as from renderedComponentsOpenAPI3.
+func documentElementsOpenAPI3(doc *openapi3.T, components *openapi3.Components, opts Options) (documentElements, error) {
+	slug, err := anchorFunc(opts)
+	if err != nil {
+		return documentElements{}, err
+	}
+	if err := checkOperationSort(opts.SortOperationsBy); err != nil {
+		return documentElements{}, err
+	}
+	d := documentElements{tags: map[string]string{}}
+	d.overview.SpecVersion = doc.OpenAPI
+	if doc.Info != nil {
+		d.overview.Title = doc.Info.Title
+		d.overview.Version = doc.Info.Version
+		d.overview.Description = doc.Info.Description
+	}
+	for _, t := range doc.Tags {
+		if t != nil {
+			d.tags[t.Name] = t.Description
+		}
+	}
+
+	operations := indexOpenAPI3Operations(doc, opts)
+	anchors := operationAnchors(operations, func(o openAPI3Op) (string, string, string) { return o.Method, o.Path, o.Op.OperationID }, slug, opts)
+	d.operations = make([]OperationElement, 0, len(operations))
+	for i, o := range operations {
+		o.Anchor = anchors[i]
+		d.operations = append(d.operations, OperationElement{
+			Method:      o.Method,
+			Path:        o.Path,
+			OperationID: o.Op.OperationID,
+			Tags:        o.Op.Tags,
+			Summary:     o.Op.Summary,
+			Description: o.Op.Description,
+			Deprecated:  o.Op.Deprecated,
+			Anchor:      o.Anchor,
+			source:      o,
+		})
+	}
+
+	d.schemas = make([]SchemaElement, 0, len(components.Schemas))
+	for _, name := range sortedKeys(components.Schemas) {
+		ref := components.Schemas[name]
+		s := SchemaElement{Name: name, Type: typeOfSchemaRef(ref), source: ref}
+		if ref != nil && ref.Value != nil {
+			props, required := mergedPropertiesOpenAPI3(ref.Value)
+			s.Description = ref.Value.Description
+			s.Properties = sortedKeys(props)
+			s.Required = required
+		}
+		d.schemas = append(d.schemas, s)
+	}
+	return d, nil
+}
+
+// documentElementsSwagger2 reduces s, as from renderedSwagger2, to its
+// elements.
+func documentElementsSwagger2(s *spec.Swagger, opts Options) (documentElements, error) {
+	slug, err := anchorFunc(opts)
+	if err != nil {
+		return documentElements{}, err
+	}
+	if err := checkOperationSort(opts.SortOperationsBy); err != nil {
+		return documentElements{}, err
+	}
+	d := documentElements{tags: map[string]string{}}
+	d.overview.SpecVersion = s.Swagger
+	if s.Info != nil {
+		d.overview.Title = s.Info.Title
+		d.overview.Version = s.Info.Version
+		d.overview.Description = s.Info.Description
+	}
+	for _, t := range s.Tags {
+		d.tags[t.Name] = t.Description
+	}
+
+	operations := indexSwagger2Operations(s, opts)
+	anchors := operationAnchors(operations, func(o swagger2Op) (string, string, string) { return o.Method, o.Path, o.Op.ID }, slug, opts)
+	d.operations = make([]OperationElement, 0, len(operations))
+	for i, o := range operations {
+		o.Anchor = anchors[i]
+		d.operations = append(d.operations, OperationElement{
+			Method:      o.Method,
+			Path:        o.Path,
+			OperationID: o.Op.ID,
+			Tags:        o.Op.Tags,
+			Summary:     o.Op.Summary,
+			Description: o.Op.Description,
+			Deprecated:  o.Op.Deprecated,
+			Anchor:      o.Anchor,
+			source:      o,
+		})
+	}
+
+	d.schemas = make([]SchemaElement, 0, len(s.Definitions))
+	for _, name := range sortedKeys(s.Definitions) {
+		sch := s.Definitions[name]
+		props, required := mergedPropertiesSwagger2(&sch, s.Definitions)
+		d.schemas = append(d.schemas, SchemaElement{
+			Name:        name,
+			Type:        schemaSummarySwagger2(&sch),
+			Description: sch.Description,
+			Properties:  sortedKeys(props),
+			Required:    required,
+			source:      sch,
+		})
+	}
+	return d, nil
+}
@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Input text encodings accepted by Options.InputEncoding. Names are matched
+// case-insensitively and a few common aliases are accepted.
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16   = "utf-16"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "iso-8859-1"
+)
+
+var encodingAliases = map[string]string{
+	"":           EncodingUTF8,
+	"utf-8":      EncodingUTF8,
+	"utf8":       EncodingUTF8,
+	"utf-16":     EncodingUTF16,
+	"utf16":      EncodingUTF16,
+	"utf-16le":   EncodingUTF16LE,
+	"utf16le":    EncodingUTF16LE,
+	"utf-16be":   EncodingUTF16BE,
+	"utf16be":    EncodingUTF16BE,
+	"iso-8859-1": EncodingLatin1,
+	"iso8859-1":  EncodingLatin1,
+	"latin-1":    EncodingLatin1,
+	"latin1":     EncodingLatin1,
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// decodeInput converts data in the named encoding to UTF-8. A UTF-16 byte
+// order mark takes precedence over the default UTF-8 so BOM-marked UTF-16
+// files work without configuration; a UTF-8 BOM is dropped.
+func decodeInput(data []byte, encoding string) ([]byte, error) {
+	enc, ok := encodingAliases[strings.ToLower(strings.TrimSpace(encoding))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported input encoding %q (supported: utf-8, utf-16, utf-16le, utf-16be, iso-8859-1)", encoding)
+	}
+
+	switch enc {
+	case EncodingUTF8, EncodingUTF16:
+		switch {
+		case bytes.HasPrefix(data, bomUTF16LE):
+			return decodeUTF16(data[2:], unicode.LittleEndian)
+		case bytes.HasPrefix(data, bomUTF16BE):
+			return decodeUTF16(data[2:], unicode.BigEndian)
+		case enc == EncodingUTF16:
+			// No BOM: big-endian, as RFC 2781 specifies.
+			return decodeUTF16(data, unicode.BigEndian)
+		}
+		return bytes.TrimPrefix(data, bomUTF8), nil
+	case EncodingUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), unicode.LittleEndian)
+	case EncodingUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), unicode.BigEndian)
+	default: // EncodingLatin1
+		return charmap.ISO8859_1.NewDecoder().Bytes(data)
+	}
+}
+
+// decodeUTF16 decodes BOM-less UTF-16 data. Unpaired surrogates become
+// U+FFFD; a trailing odd byte is an error rather than a replacement.
+func decodeUTF16(data []byte, order unicode.Endianness) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("invalid UTF-16 input: odd length %d", len(data))
+	}
+	return unicode.UTF16(order, unicode.IgnoreBOM).NewDecoder().Bytes(data)
+}
@@ -0,0 +1,166 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// Errors section: the schema named by Options.ErrorSchemaName documented once,
+// with a table of the responses that return it.
+
+// errorField is one property of the error schema.
+type errorField struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// errorUse is one response whose body is the error schema.
+type errorUse struct {
+	Method      string
+	Path        string
+	Anchor      string
+	Code        string
+	Description string
+}
+
+// errorFieldsOpenAPI3 lists the properties of s, allOf members included,
+// sorted by name.
+func errorFieldsOpenAPI3(s *openapi3.Schema) []errorField {
+	props, required := mergedPropertiesOpenAPI3(s)
+	fields := make([]errorField, 0, len(props))
+	for _, pn := range sortedKeys(props) {
+		f := errorField{Name: pn, Type: typeOfSchemaRef(props[pn]), Required: contains(required, pn)}
+		if ps := props[pn]; ps != nil && ps.Value != nil {
+			f.Description = strings.TrimSpace(ps.Value.Description)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// errorUsesOpenAPI3 lists the responses of operations with a media type whose
+// schema is a $ref to the schema called name, in operation and response
+// order.
+func errorUsesOpenAPI3(operations []openAPI3Op, name string) []errorUse {
+	var uses []errorUse
+	for _, ref := range operations {
+		if ref.Op.Responses == nil {
+			continue
+		}
+		respMap := ref.Op.Responses.Map()
+		codes := make([]string, 0, len(respMap))
+		for code := range respMap {
+			codes = append(codes, code)
+		}
+		sortResponseCodes(codes)
+		for _, code := range codes {
+			r := respMap[code]
+			if r == nil || r.Value == nil {
+				continue
+			}
+			for _, media := range r.Value.Content {
+				if media.Schema != nil && refName(media.Schema.Ref) == name {
+					desc := ""
+					if r.Value.Description != nil {
+						desc = strings.TrimSpace(*r.Value.Description)
+					}
+					uses = append(uses, errorUse{ref.Method, ref.Path, ref.Anchor, strings.TrimSpace(code), desc})
+					break
+				}
+			}
+		}
+	}
+	return uses
+}
+
+// errorFieldsSwagger2 is errorFieldsOpenAPI3 for a Swagger 2.0 definition.
+func errorFieldsSwagger2(s *spec.Schema, defs spec.Definitions) []errorField {
+	props, required := mergedPropertiesSwagger2(s, defs)
+	fields := make([]errorField, 0, len(props))
+	for _, pn := range sortedKeys(props) {
+		ps := props[pn]
+		fields = append(fields, errorField{
+			Name:        pn,
+			Type:        nonEmpty(schemaSummarySwagger2(&ps), "-"),
+			Required:    contains(required, pn),
+			Description: strings.TrimSpace(ps.Description),
+		})
+	}
+	return fields
+}
+
+// errorUsesSwagger2 is errorUsesOpenAPI3 for Swagger 2.0 operations, whose
+// responses are listed as writeSwagger2Operation lists them: status codes,
+// then the named responses in unknown, then default.
+func errorUsesSwagger2(operations []swagger2Op, unknown map[*spec.Operation][]namedResponse, name string) []errorUse {
+	var uses []errorUse
+	add := func(ref swagger2Op, code string, r *spec.Response) {
+		if r.Schema != nil && refName(r.Schema.Ref.String()) == name {
+			uses = append(uses, errorUse{ref.Method, ref.Path, ref.Anchor, code, strings.TrimSpace(r.Description)})
+		}
+	}
+	for _, ref := range operations {
+		if ref.Op.Responses != nil {
+			codes := make([]int, 0, len(ref.Op.Responses.StatusCodeResponses))
+			for code := range ref.Op.Responses.StatusCodeResponses {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			for _, code := range codes {
+				r := ref.Op.Responses.StatusCodeResponses[code]
+				add(ref, strconv.Itoa(code), &r)
+			}
+		}
+		for _, nr := range unknown[ref.Op] {
+			add(ref, nr.Key, &nr.Response)
+		}
+		if ref.Op.Responses != nil && ref.Op.Responses.Default != nil {
+			add(ref, "default", ref.Op.Responses.Default)
+		}
+	}
+	return uses
+}
+
+// writeErrorsSection emits the "## Errors" section for the error schema
+// called name: its description, a table of its fields, and a table of the
+// operations and status codes that return it.
+func writeErrorsSection(b *bytes.Buffer, name, description string, fields []errorField, uses []errorUse, schemaLink func(string) string, opts Options) {
+	b.WriteByte('\n')
+	writeAnchor(b, "Errors", opts)
+	fmt.Fprintf(b, "## Errors\n")
+	fmt.Fprintf(b, "Error responses share the %s schema, documented here once.\n", schemaLink(name))
+	if description = strings.TrimSpace(description); description != "" {
+		fmt.Fprintf(b, "\n%s\n", description)
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n| Field | Type | Required | Description |\n|---|---|---|---|\n")
+		for _, f := range fields {
+			req := "no"
+			if f.Required {
+				req = "yes"
+			}
+			fmt.Fprintf(b, "| `%s` | %s | %s | %s |\n", f.Name, tableCell(f.Type), req, tableCell(f.Description))
+		}
+	}
+	if len(uses) > 0 {
+		b.WriteString("\n| Operation | Status | Description |\n|---|---|---|\n")
+		for _, u := range uses {
+			fmt.Fprintf(b, "| [%s %s](#%s) | %s | %s |\n", u.Method, u.Path, u.Anchor, u.Code, tableCell(u.Description))
+		}
+	}
+}
+
+// tableCell makes s safe inside a Markdown table cell: pipes are escaped and
+// line breaks become spaces.
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-openapi/spec"
@@ -12,6 +16,33 @@ import (
 
 // Shared helpers across Swagger 2.0 and OpenAPI 3.x markdown generation.
 
+// bufPool recycles the document buffers the writers render into, so batch
+// conversions don't regrow a fresh buffer for every spec.
+var bufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from bufPool.
+func getBuffer() *bytes.Buffer {
+	b := bufPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+// maxPooledBuffer is the largest buffer capacity putBuffer keeps. Rendering
+// one very large spec should not pin its buffer for the rest of the process.
+const maxPooledBuffer = 1 << 20
+
+// putBuffer hands b back to bufPool, or drops it if it has grown past
+// maxPooledBuffer. Callers must not retain b afterwards; b.String() copies,
+// so returning its result is safe.
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	bufPool.Put(b)
+}
+
 // nonEmpty returns s if it is non-empty, otherwise fallback.
 func nonEmpty(s, fallback string) string {
 	if s == "" {
@@ -36,17 +67,502 @@ func defaultAsString(v any) string {
 	return fmt.Sprintf("%v", v)
 }
 
+// enumAsString joins enum values for an inline "[enum: ...]" list. String
+// values are quoted so they read differently from numbers, booleans, and
+// null: "1", 1, "true", true.
 func enumAsString(list []any) string {
 	if len(list) == 0 {
 		return ""
 	}
 	var parts []string
 	for _, v := range list {
-		parts = append(parts, fmt.Sprintf("%v", v))
+		switch v := v.(type) {
+		case string:
+			parts = append(parts, strconv.Quote(v))
+		case nil:
+			parts = append(parts, "null")
+		default:
+			parts = append(parts, fmt.Sprintf("%v", v))
+		}
 	}
 	return strings.Join(parts, ", ")
 }
 
+// propertiesBounds formats minProperties/maxProperties as
+// "[properties: min=1, max=50]", omitting whichever bound is unset.
+// It returns "" when neither bound is set.
+func propertiesBounds(lo, hi *int64) string {
+	var parts []string
+	if lo != nil {
+		parts = append(parts, fmt.Sprintf("min=%d", *lo))
+	}
+	if hi != nil {
+		parts = append(parts, fmt.Sprintf("max=%d", *hi))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[properties: %s]", strings.Join(parts, ", "))
+}
+
+// numericBounds formats minimum/maximum as "[min: 1, max: 10]", prefixing a
+// bound with "exclusive" when the corresponding exclusive flag is set (the
+// OpenAPI 3.0 / Swagger 2.0 form; 3.1's numeric form is normalized to it
+// before parsing). It returns "" when neither bound is set.
+func numericBounds(lo, hi *float64, exclusiveLo, exclusiveHi bool) string {
+	var parts []string
+	if lo != nil {
+		label := "min"
+		if exclusiveLo {
+			label = "exclusive min"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", label, strconv.FormatFloat(*lo, 'g', -1, 64)))
+	}
+	if hi != nil {
+		label := "max"
+		if exclusiveHi {
+			label = "exclusive max"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", label, strconv.FormatFloat(*hi, 'g', -1, 64)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, ", "))
+}
+
+// formatConstraints renders string length and pattern constraints as
+// "[constraints: minLength: 1, maxLength: 64, pattern: `^[a-z]+$`]", or ""
+// when there are none. Both spec versions call it with their own schema or
+// parameter fields.
+func formatConstraints(minLength, maxLength *int64, pattern string) string {
+	var parts []string
+	if minLength != nil {
+		parts = append(parts, fmt.Sprintf("minLength: %d", *minLength))
+	}
+	if maxLength != nil {
+		parts = append(parts, fmt.Sprintf("maxLength: %d", *maxLength))
+	}
+	if pattern != "" {
+		parts = append(parts, "pattern: "+inlineCode(pattern))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[constraints: %s]", strings.Join(parts, ", "))
+}
+
+// inlineCode wraps s in a code span. The backtick fence is one longer than
+// the longest backtick run in s, and s is padded with spaces when it starts
+// or ends with a backtick, so values such as "a`b" survive intact.
+func inlineCode(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// literal returns s as is, or as inline code when it contains characters
+// Markdown would interpret, such as the "*" and "_" of a regex default.
+func literal(s string) string {
+	if strings.ContainsAny(s, "`*_[]<>|\\~#") {
+		return inlineCode(s)
+	}
+	return s
+}
+
+// constraintsOpenAPI3 is formatConstraints for a kin-openapi schema, where
+// an unset minLength is 0.
+func constraintsOpenAPI3(s *openapi3.Schema) string {
+	var minLength, maxLength *int64
+	if s.MinLength > 0 {
+		v := int64(s.MinLength)
+		minLength = &v
+	}
+	if s.MaxLength != nil {
+		v := int64(*s.MaxLength)
+		maxLength = &v
+	}
+	return formatConstraints(minLength, maxLength, s.Pattern)
+}
+
+// writePatternProperties lists an OpenAPI 3.1 schema's patternProperties as
+// "- `regex` → type", sorted by pattern. kin-openapi has no field for the
+// keyword, so it is read back from the schema's extensions.
+func writePatternProperties(b *bytes.Buffer, exts map[string]any) {
+	patterns, ok := exts["patternProperties"].(map[string]any)
+	if !ok || len(patterns) == 0 {
+		return
+	}
+	keys := sortedKeys(patterns)
+	fmt.Fprintf(b, "\n**Pattern-keyed properties**\n")
+	for _, k := range keys {
+		typ := "-"
+		if raw, err := json.Marshal(patterns[k]); err == nil {
+			var ref openapi3.SchemaRef
+			switch {
+			case json.Unmarshal(raw, &ref) != nil:
+			case ref.Ref != "":
+				typ = "$ref:" + refName(ref.Ref)
+			default:
+				typ = typeOfSchemaRef(&ref)
+			}
+		}
+		fmt.Fprintf(b, "- `%s` → %s\n", k, typ)
+	}
+}
+
+// writeAdditionalProperties notes what a schema says about properties it
+// does not declare: none are allowed (allowed is false), any are (true), or
+// each must match mapType when additionalProperties is a schema, making the
+// object a typed map. Nothing is written when the keyword is absent.
+func writeAdditionalProperties(b *bytes.Buffer, allowed *bool, mapType string) {
+	var note string
+	switch {
+	case mapType != "":
+		note = fmt.Sprintf("(map — additional properties: %s)", mapType)
+	case allowed == nil:
+		return
+	case *allowed:
+		note = "(open — additional properties of any type)"
+	default:
+		note = "(closed — no additional properties)"
+	}
+	blankLineUnlessHeading(b)
+	fmt.Fprintf(b, "%s\n", note)
+}
+
+// defaultResponseNote follows the "default" response key in response lists,
+// since readers often take it for a status code.
+const defaultResponseNote = "applies to all undocumented status codes"
+
+// responseLabel returns the list label for a response key: the key itself,
+// with defaultResponseNote appended for "default".
+func responseLabel(code string) string {
+	if code == "default" {
+		return code + " — " + defaultResponseNote
+	}
+	return code
+}
+
+// formatSecurityRequirements renders a list of security requirements the way
+// the spec defines them: alternatives are OR'd, and the schemes within a single
+// requirement are AND'd. A requirement naming several schemes is parenthesized,
+// OAuth2/OpenID scopes follow the scheme name in brackets, and an empty
+// requirement ({}) renders as "anonymous" since it makes auth optional.
+// An empty list means the operation is explicitly public. When describe is
+// non-nil, each scope is followed by the description it returns, if any:
+// "read (Read access to pets)".
+func formatSecurityRequirements(reqs []map[string][]string, describe func(scheme, scope string) string) string {
+	if len(reqs) == 0 {
+		return "None (public)"
+	}
+	alts := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if len(req) == 0 {
+			alts = append(alts, "anonymous")
+			continue
+		}
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			if scopes := req[name]; len(scopes) > 0 {
+				labels := make([]string, len(scopes))
+				for i, scope := range scopes {
+					labels[i] = scope
+					if describe == nil {
+						continue
+					}
+					if desc := strings.TrimSpace(describe(name, scope)); desc != "" {
+						labels[i] = fmt.Sprintf("%s (%s)", scope, desc)
+					}
+				}
+				parts = append(parts, fmt.Sprintf("%s [%s]", name, strings.Join(labels, ", ")))
+			} else {
+				parts = append(parts, name)
+			}
+		}
+		if len(parts) == 1 {
+			alts = append(alts, parts[0])
+		} else {
+			alts = append(alts, "("+strings.Join(parts, " AND ")+")")
+		}
+	}
+	return strings.Join(alts, " OR ")
+}
+
+// requiredHeaders aggregates the headers a request must send: those implied
+// by the security requirements, then the required header parameters, each
+// listed once (header names compare case-insensitively). headerOf returns
+// the header an apiKey-in-header scheme reads, or "" for other schemes.
+// Security headers only count when every alternative needs one; differing
+// alternatives are listed together as "`X-API-Key` or `X-Token`".
+func requiredHeaders(reqs []map[string][]string, headerOf func(scheme string) string, params []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(entry string, names ...string) {
+		for _, n := range names {
+			if seen[strings.ToLower(n)] {
+				return
+			}
+		}
+		for _, n := range names {
+			seen[strings.ToLower(n)] = true
+		}
+		out = append(out, entry)
+	}
+
+	alts := make([][]string, 0, len(reqs))
+	for _, req := range reqs {
+		var names []string
+		for _, scheme := range sortedKeys(req) {
+			if h := headerOf(scheme); h != "" {
+				names = append(names, h)
+			}
+		}
+		if len(names) == 0 {
+			alts = nil
+			break
+		}
+		alts = append(alts, names)
+	}
+	same := true
+	for _, alt := range alts {
+		same = same && strings.Join(alt, "\x00") == strings.Join(alts[0], "\x00")
+	}
+	switch {
+	case len(alts) == 0:
+	case same:
+		for _, h := range alts[0] {
+			add("`"+h+"`", h)
+		}
+	default:
+		var all, parts []string
+		for _, alt := range alts {
+			quoted := make([]string, len(alt))
+			for i, h := range alt {
+				quoted[i] = "`" + h + "`"
+			}
+			parts = append(parts, strings.Join(quoted, " + "))
+			all = append(all, alt...)
+		}
+		add(strings.Join(parts, " or "), all...)
+	}
+
+	for _, h := range params {
+		add("`"+h+"`", h)
+	}
+	return out
+}
+
+// bodyNoteOpenAPI3 describes a response body whose media type or schema
+// format says more than its schema type would: "binary body (format:
+// binary)" for octet-stream, image, audio, and video types or binary
+// strings, "plain text body" for text/plain, and "text body" for other
+// text types. It returns "" when the schema is more than an untyped or
+// string schema, so structured bodies keep their schema line.
+func bodyNoteOpenAPI3(mediaType string, schema *openapi3.SchemaRef) string {
+	var format string
+	if schema != nil && schema.Value != nil {
+		v := schema.Value
+		if schema.Ref != "" || len(v.Properties) > 0 || v.Items != nil || len(v.OneOf) > 0 || len(v.AnyOf) > 0 || len(v.AllOf) > 0 {
+			return ""
+		}
+		if v.Type != nil && !v.Type.Is("string") {
+			return ""
+		}
+		format = v.Format
+	}
+	withFormat := func(note string) string {
+		if format != "" {
+			return fmt.Sprintf("%s (format: %s)", note, format)
+		}
+		return note
+	}
+	mt, _, _ := strings.Cut(strings.ToLower(mediaType), ";")
+	mt = strings.TrimSpace(mt)
+	switch {
+	case format == "binary", mt == "application/octet-stream",
+		strings.HasPrefix(mt, "image/"), strings.HasPrefix(mt, "audio/"), strings.HasPrefix(mt, "video/"):
+		return withFormat(binaryBodyNote)
+	case format == "byte":
+		return withFormat(base64BytesNote)
+	case mt == "text/plain":
+		return withFormat("plain text body")
+	case strings.HasPrefix(mt, "text/"):
+		return withFormat("text body")
+	}
+	return ""
+}
+
+// Notes that replace "string" for a string schema carrying raw bytes; see
+// byteFormatNote.
+const (
+	binaryUploadNote = "binary upload"
+	binaryBodyNote   = "binary body"
+	binaryFileNote   = "binary file"
+	base64BytesNote  = "base64-encoded bytes"
+)
+
+// byteFormatNote describes a string (or untyped) schema whose format says it
+// carries bytes: binaryNote for format binary (binaryUploadNote for request
+// bodies, binaryBodyNote for responses, binaryFileNote for properties) and
+// base64BytesNote for format byte. It returns "" for any other schema.
+func byteFormatNote(types []string, format, binaryNote string) string {
+	if len(types) > 1 || len(types) == 1 && types[0] != "string" {
+		return ""
+	}
+	switch format {
+	case "binary":
+		return binaryNote
+	case "byte":
+		return base64BytesNote
+	}
+	return ""
+}
+
+// parameterLocations lists the parameter subsections written when
+// Options.GroupParametersByLocation is set, in display order. formData only
+// occurs in Swagger 2.0.
+var parameterLocations = []struct{ in, title string }{
+	{"path", "Path Parameters"},
+	{"query", "Query Parameters"},
+	{"header", "Header Parameters"},
+	{"cookie", "Cookie Parameters"},
+	{"formData", "Form Parameters"},
+}
+
+// writeParameterGroups writes params under one "**<Location> Parameters**"
+// heading per location, keeping their order within each. Parameters with
+// any other location go last under "**Other Parameters**"; those for which
+// in returns "" are skipped.
+func writeParameterGroups[P any](b *bytes.Buffer, params []P, in func(P) string, write func(P)) {
+	writeGroup := func(title string, keep func(loc string) bool) {
+		var group []P
+		for _, p := range params {
+			if loc := in(p); loc != "" && keep(loc) {
+				group = append(group, p)
+			}
+		}
+		if len(group) == 0 {
+			return
+		}
+		blankLine(b)
+		fmt.Fprintf(b, "**%s**\n", title)
+		for _, p := range group {
+			write(p)
+		}
+	}
+	known := map[string]bool{}
+	for _, l := range parameterLocations {
+		known[l.in] = true
+		writeGroup(l.title, func(loc string) bool { return loc == l.in })
+	}
+	writeGroup("Other Parameters", func(loc string) bool { return !known[loc] })
+}
+
+// splitDeprecated separates params into active and deprecated ones, keeping
+// their order, for Options.GroupDeprecatedParameters.
+func splitDeprecated[P any](params []P, deprecated func(P) bool) (active, dep []P) {
+	for _, p := range params {
+		if deprecated(p) {
+			dep = append(dep, p)
+		} else {
+			active = append(active, p)
+		}
+	}
+	return active, dep
+}
+
+// writeDeprecatedParameters writes params under a "**Deprecated Parameters**"
+// heading, if there are any.
+func writeDeprecatedParameters[P any](b *bytes.Buffer, params []P, write func(P)) {
+	if len(params) == 0 {
+		return
+	}
+	blankLine(b)
+	fmt.Fprintf(b, "**Deprecated Parameters**\n")
+	for _, p := range params {
+		write(p)
+	}
+}
+
+// schemaNotSpecified replaces "schema: -" for a media type that only gives
+// examples, which are rendered below it as usual.
+const schemaNotSpecified = "(schema not specified)"
+
+// exampleOnlyOpenAPI3 reports whether a media type has an example or named
+// examples but no schema.
+func exampleOnlyOpenAPI3(media *openapi3.MediaType) bool {
+	return media != nil && media.Schema == nil && (media.Example != nil || len(media.Examples) > 0)
+}
+
+// operationReference is an operation listed by link: under a secondary tag
+// when Options.PrimaryTagOnly is set, or under a schema it uses when
+// Options.SchemaUsageBackrefs is set (without PrimaryTag).
+type operationReference struct {
+	Method, Path, Anchor, PrimaryTag string
+}
+
+// writeOperationReferences emits the "**Also in this tag**" list linking to
+// operations rendered in full under their primary tag.
+func writeOperationReferences(b *bytes.Buffer, refs []operationReference) {
+	if len(refs) == 0 {
+		return
+	}
+	blankLine(b)
+	fmt.Fprintf(b, "**Also in this tag**\n")
+	for _, r := range refs {
+		fmt.Fprintf(b, "- [%s %s](#%s) — under %s\n", r.Method, r.Path, r.Anchor, r.PrimaryTag)
+	}
+}
+
+// writeSchemaUsers emits the "**Used by**" list under a schema, linking to
+// the operations that reference it.
+func writeSchemaUsers(b *bytes.Buffer, refs []operationReference) {
+	if len(refs) == 0 {
+		return
+	}
+	blankLineUnlessHeading(b)
+	fmt.Fprintf(b, "**Used by**\n")
+	for _, r := range refs {
+		fmt.Fprintf(b, "- [%s %s](#%s)\n", r.Method, r.Path, r.Anchor)
+	}
+}
+
+// writeRequiredHeaders emits the "**Required headers:**" line, if any.
+func writeRequiredHeaders(b *bytes.Buffer, headers []string) {
+	if len(headers) == 0 {
+		return
+	}
+	blankLine(b)
+	fmt.Fprintf(b, "**Required headers:** %s\n", strings.Join(headers, ", "))
+}
+
+// securityRequirementsOpenAPI3 converts kin-openapi requirements into the plain
+// form accepted by formatSecurityRequirements.
+func securityRequirementsOpenAPI3(reqs openapi3.SecurityRequirements) []map[string][]string {
+	out := make([]map[string][]string, 0, len(reqs))
+	for _, req := range reqs {
+		out = append(out, map[string][]string(req))
+	}
+	return out
+}
+
 // schemaSummarySwagger2 returns a concise description of a Swagger 2.0 schema
 // suitable for inline use in response summaries.
 func schemaSummarySwagger2(s *spec.Schema) string {
@@ -75,13 +591,31 @@ func schemaSummarySwagger2(s *spec.Schema) string {
 	}
 	if len(s.Type) > 0 {
 		if s.Format != "" {
-			return fmt.Sprintf("%s (%s)", strings.Join(s.Type, ","), s.Format)
+			return fmt.Sprintf("%s (%s)", strings.Join(s.Type, ","), literal(s.Format))
 		}
 		return strings.Join(s.Type, ",")
 	}
 	return ""
 }
 
+// writeModelIndex emits a "**Models:**" line linking each named schema to its
+// heading in the Schemas section, using slug to build the anchors. Names
+// without a heading (linkable reports false) are listed as plain text.
+func writeModelIndex(b *bytes.Buffer, names []string, slug func(string) string, linkable func(string) bool) {
+	if len(names) == 0 {
+		return
+	}
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if linkable(name) {
+			parts = append(parts, fmt.Sprintf("[%s](#%s)", name, slug(name)))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	fmt.Fprintf(b, "**Models:** %s\n", strings.Join(parts, ", "))
+}
+
 func refName(ref string) string {
 	if ref == "" {
 		return ""
@@ -93,18 +627,50 @@ func refName(ref string) string {
 	return ref
 }
 
-func hostURL(schemes []string, host, basePath string) string {
-	s := "http"
-	if len(schemes) > 0 {
-		s = schemes[0]
-	}
+// hostURLs returns the Swagger 2.0 base URL for each of schemes, in order and
+// without repeats, or a single http URL when schemes is empty. It returns nil
+// when host is empty.
+func hostURLs(schemes []string, host, basePath string) []string {
 	if host == "" {
-		return ""
+		return nil
+	}
+	var urls []string
+	seen := map[string]bool{}
+	for _, scheme := range schemes {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if scheme == "" || seen[scheme] {
+			continue
+		}
+		seen[scheme] = true
+		urls = append(urls, fmt.Sprintf("%s://%s%s", scheme, host, basePath))
+	}
+	if len(urls) == 0 {
+		urls = []string{fmt.Sprintf("http://%s%s", host, basePath)}
+	}
+	return urls
+}
+
+// primaryServer returns the index of the server marked "(primary)" when a
+// Servers list has several: the first whose URL or description contains
+// opts.PrimaryServerMatch (case-insensitively) when that is set, falling
+// back to the first server when none does, otherwise opts.PrimaryServer.
+func primaryServer(servers openapi3.Servers, opts Options) (int, error) {
+	if opts.PrimaryServerMatch != "" {
+		needle := strings.ToLower(opts.PrimaryServerMatch)
+		for i, s := range servers {
+			if s == nil {
+				continue
+			}
+			if strings.Contains(strings.ToLower(s.URL), needle) || strings.Contains(strings.ToLower(s.Description), needle) {
+				return i, nil
+			}
+		}
+		return 0, nil
 	}
-	if basePath == "" {
-		return fmt.Sprintf("%s://%s", s, host)
+	if opts.PrimaryServer < 0 || (len(servers) > 0 && opts.PrimaryServer >= len(servers)) {
+		return 0, fmt.Errorf("primary server index %d out of range (%d servers)", opts.PrimaryServer, len(servers))
 	}
-	return fmt.Sprintf("%s://%s%s", s, host, basePath)
+	return opts.PrimaryServer, nil
 }
 
 func typeOfSchemaRef(ref *openapi3.SchemaRef) string {
@@ -139,6 +705,307 @@ func typeOfSchemaRef(ref *openapi3.SchemaRef) string {
 	return "object"
 }
 
+// typeLabel formats a property's type for schema listings as "(type)". An
+// inline schema's title is appended as "(type) (title: T)", or replaces the
+// type as "(T)" when preferTitle is set. Titles are ignored for $ref schemas,
+// which already render the referenced name.
+func typeLabel(typ, title string, isRef, preferTitle bool) string {
+	title = strings.TrimSpace(title)
+	switch {
+	case title == "" || isRef:
+		return fmt.Sprintf("(%s)", typ)
+	case preferTitle:
+		return fmt.Sprintf("(%s)", title)
+	default:
+		return fmt.Sprintf("(%s) (title: %s)", typ, title)
+	}
+}
+
+// sortResponseCodes orders OpenAPI 3.x response keys for display: numeric
+// status codes first (numerically), then ranges such as "2XX", then any other
+// non-standard key verbatim, and "default" last. Keys are compared with surrounding
+// whitespace trimmed, since padded keys like "200 " are common in the wild.
+func sortResponseCodes(codes []string) {
+	rank := func(code string) int {
+		c := strings.TrimSpace(code)
+		if _, err := strconv.Atoi(c); err == nil {
+			return 0
+		}
+		if len(c) == 3 && c[0] >= '1' && c[0] <= '5' && strings.EqualFold(c[1:], "XX") {
+			return 1
+		}
+		if c == "default" {
+			return 3
+		}
+		return 2
+	}
+	sort.SliceStable(codes, func(i, j int) bool {
+		ri, rj := rank(codes[i]), rank(codes[j])
+		if ri != rj {
+			return ri < rj
+		}
+		ci, cj := strings.TrimSpace(codes[i]), strings.TrimSpace(codes[j])
+		if ri == 0 {
+			ni, _ := strconv.Atoi(ci)
+			nj, _ := strconv.Atoi(cj)
+			if ni != nj {
+				return ni < nj
+			}
+		}
+		if ci != cj {
+			return ci < cj
+		}
+		return codes[i] < codes[j]
+	})
+}
+
+// changelogEntry is one release in a root x-changelog extension.
+type changelogEntry struct {
+	Version string
+	Date    string
+	Notes   []string
+}
+
+// parseChangelog decodes the generic JSON value of a changelog extension.
+// Entries that are not objects are skipped; the result is ordered newest-first
+// by date, with undated entries last in their original order.
+func parseChangelog(raw any) []changelogEntry {
+	list, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+	var entries []changelogEntry
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		e := changelogEntry{
+			Version: strings.TrimSpace(defaultAsString(m["version"])),
+			Date:    strings.TrimSpace(defaultAsString(m["date"])),
+		}
+		switch notes := m["notes"].(type) {
+		case string:
+			if n := strings.TrimSpace(notes); n != "" {
+				e.Notes = append(e.Notes, n)
+			}
+		case []any:
+			for _, n := range notes {
+				if n := strings.TrimSpace(defaultAsString(n)); n != "" {
+					e.Notes = append(e.Notes, n)
+				}
+			}
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date == "" || entries[j].Date == "" {
+			return entries[j].Date == "" && entries[i].Date != ""
+		}
+		return entries[i].Date > entries[j].Date
+	})
+	return entries
+}
+
+// writeLogo renders the ReDoc "x-logo" info extension ({"url", "altText",
+// "href"}) as a Markdown image, linked to href when set. A logo without a
+// URL is ignored.
+func writeLogo(b *bytes.Buffer, exts map[string]any) {
+	logo, _ := exts["x-logo"].(map[string]any)
+	url, _ := logo["url"].(string)
+	if url = strings.TrimSpace(url); url == "" {
+		return
+	}
+	alt, _ := logo["altText"].(string)
+	img := fmt.Sprintf("![%s](%s)", nonEmpty(strings.TrimSpace(alt), "logo"), url)
+	if href, _ := logo["href"].(string); strings.TrimSpace(href) != "" {
+		img = fmt.Sprintf("[%s](%s)", img, strings.TrimSpace(href))
+	}
+	fmt.Fprintf(b, "%s\n\n", img)
+}
+
+// writeChangelog emits the "## Changelog" section for the extension named key
+// in exts, if present and non-empty.
+func writeChangelog(b *bytes.Buffer, exts map[string]any, key string) {
+	if key == "" {
+		key = DefaultChangelogKey
+	}
+	entries := parseChangelog(exts[key])
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## Changelog\n")
+	for _, e := range entries {
+		fmt.Fprintf(b, "- %s", nonEmpty(e.Version, "-"))
+		if e.Date != "" {
+			fmt.Fprintf(b, " (%s)", e.Date)
+		}
+		if len(e.Notes) == 1 {
+			fmt.Fprintf(b, " — %s", e.Notes[0])
+		}
+		b.WriteByte('\n')
+		if len(e.Notes) > 1 {
+			for _, n := range e.Notes {
+				fmt.Fprintf(b, "  - %s\n", n)
+			}
+		}
+	}
+}
+
+// defaultEnumDescriptionKeys are the extensions read for enum value
+// descriptions when Options.EnumDescriptionKeys is empty.
+var defaultEnumDescriptionKeys = []string{"x-enum-descriptions", "x-enumDescriptions"}
+
+// enumDescriptions returns a description for each value in enum, taken from
+// the first extension in keys present in exts. The extension may be a list
+// parallel to enum or an object keyed by the value's string form. It returns
+// nil when no extension applies.
+func enumDescriptions(exts map[string]any, enum []any, keys []string) []string {
+	if len(enum) == 0 {
+		return nil
+	}
+	if len(keys) == 0 {
+		keys = defaultEnumDescriptionKeys
+	}
+	for _, key := range keys {
+		descs := make([]string, len(enum))
+		switch v := exts[key].(type) {
+		case []any:
+			for i := range enum {
+				if i < len(v) {
+					descs[i] = strings.TrimSpace(defaultAsString(v[i]))
+				}
+			}
+		case map[string]any:
+			for i, e := range enum {
+				descs[i] = strings.TrimSpace(defaultAsString(v[fmt.Sprintf("%v", e)]))
+			}
+		default:
+			continue
+		}
+		return descs
+	}
+	return nil
+}
+
+// enumItem is one enum value with its optional description and deprecation.
+type enumItem struct {
+	Value       any
+	Description string
+	Deprecated  bool
+}
+
+// enumItems pairs each enum value with its description (see
+// enumDescriptions) and marks values listed in the deprecated-enum extension.
+// It returns nil when neither extension applies, so callers keep the inline
+// enum list.
+func enumItems(exts map[string]any, enum []any, opts Options) []enumItem {
+	descs := enumDescriptions(exts, enum, opts.EnumDescriptionKeys)
+	key := opts.DeprecatedEnumKey
+	if key == "" {
+		key = DefaultDeprecatedEnumKey
+	}
+	deprecated, _ := exts[key].([]any)
+	if len(enum) == 0 || (descs == nil && len(deprecated) == 0) {
+		return nil
+	}
+	items := make([]enumItem, len(enum))
+	for i, v := range enum {
+		items[i].Value = v
+		if descs != nil {
+			items[i].Description = descs[i]
+		}
+		for _, d := range deprecated {
+			if fmt.Sprintf("%v", d) == fmt.Sprintf("%v", v) {
+				items[i].Deprecated = true
+				break
+			}
+		}
+	}
+	return items
+}
+
+// writeEnumItems emits one indented sub-item per enum value, with its
+// deprecation marker and description when present.
+func writeEnumItems(b *bytes.Buffer, items []enumItem) {
+	for _, it := range items {
+		fmt.Fprintf(b, "  - `%v`", it.Value)
+		if it.Deprecated {
+			b.WriteString(" (deprecated)")
+		}
+		if it.Description != "" {
+			fmt.Fprintf(b, " — %s", it.Description)
+		}
+		b.WriteByte('\n')
+	}
+}
+
+// rawObjectAt walks raw JSON along the given object keys and returns the
+// members of the object found there, or nil if the path does not exist.
+func rawObjectAt(raw []byte, path ...string) map[string]json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil {
+		return nil
+	}
+	for _, key := range path {
+		next, ok := obj[key]
+		if !ok {
+			return nil
+		}
+		obj = nil
+		if json.Unmarshal(next, &obj) != nil {
+			return nil
+		}
+	}
+	return obj
+}
+
+// writeRawSchema emits a collapsible fenced block with the source JSON of a
+// schema. When the source is unavailable (documents built in code), fallback
+// is marshaled instead.
+func writeRawSchema(b *bytes.Buffer, raw json.RawMessage, fallback any) {
+	writeRawJSON(b, "Raw schema", raw, fallback)
+}
+
+// writeRawJSON emits raw, indented, in a collapsible fenced block under the
+// given summary, or fallback marshaled when raw is empty or not JSON.
+func writeRawJSON(b *bytes.Buffer, summary string, raw []byte, fallback any) {
+	var content bytes.Buffer
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || json.Indent(&content, raw, "", "  ") != nil {
+		content.Reset()
+		buf, err := json.MarshalIndent(fallback, "", "  ")
+		if err != nil {
+			return
+		}
+		content.Write(buf)
+	}
+	fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n```json\n%s\n```\n\n</details>\n", summary, content.String())
+}
+
+// writeSourceSpecAppendix emits the "## Appendix: Source Spec" section for
+// Options.AppendRawSpec: the spec as normalized JSON, or fallback marshaled
+// for documents built in code. A source larger than the limit is left out
+// with a note.
+func writeSourceSpecAppendix(b *bytes.Buffer, raw []byte, fallback any, opts Options) {
+	limit := opts.MaxRawSpecBytes
+	if limit <= 0 {
+		limit = DefaultMaxRawSpecBytes
+	}
+	if len(raw) == 0 {
+		raw, _ = json.Marshal(fallback)
+	}
+	fmt.Fprintf(b, "\n## Appendix: Source Spec\n\n")
+	if len(raw) > limit {
+		fmt.Fprintf(b, "The source spec (%d bytes) is larger than the %d-byte limit and is not included.\n", len(raw), limit)
+		return
+	}
+	writeRawJSON(b, "Source spec (JSON)", raw, fallback)
+}
+
 // -------- Example rendering helpers --------
 
 // fenceLanguage picks a code block language hint based on media type and whether
@@ -185,14 +1052,414 @@ func exampleToPrettyString(v any) (s string, isJSON bool) {
 
 // writeExampleFence emits a labeled fenced code block for an example.
 func writeExampleFence(b *bytes.Buffer, label, mediaType string, v any) {
+	writeNamedExampleFence(b, label, "", "", mediaType, v)
+}
+
+// writeNamedExampleFence is writeExampleFence for OpenAPI 3.x named examples,
+// which may carry a summary and description explaining what they demonstrate.
+// When either is set it is rendered on its own line between the label and the
+// fence, as "_summary_ — description".
+func writeNamedExampleFence(b *bytes.Buffer, label, summary, description, mediaType string, v any) {
 	content, isJSON := exampleToPrettyString(v)
 	lang := fenceLanguage(mediaType, isJSON)
+	if containsRef(v) {
+		label = strings.TrimSpace(label + " " + unresolvedRefNote)
+	}
 	if label != "" {
 		fmt.Fprintf(b, "%s\n", label)
 	}
+	summary = strings.TrimSpace(summary)
+	description = strings.TrimSpace(description)
+	switch {
+	case summary != "" && description != "":
+		fmt.Fprintf(b, "_%s_ — %s\n", summary, description)
+	case summary != "":
+		fmt.Fprintf(b, "_%s_\n", summary)
+	case description != "":
+		fmt.Fprintf(b, "%s\n", description)
+	}
 	if lang != "" {
 		fmt.Fprintf(b, "```%s\n%s\n```\n", lang, content)
 	} else {
 		fmt.Fprintf(b, "```\n%s\n```\n", content)
 	}
 }
+
+// namedExample is one named example of a media type.
+type namedExample struct {
+	Name    string
+	Summary string
+	Value   any
+}
+
+// writeExampleComparison writes two or more named examples side by side: a
+// table with one column per example, headed by its summary (or name), and
+// one row per top-level key. Names, keys, and values are escaped for table
+// cells. It writes nothing and returns false unless every example is a JSON
+// object of scalar values.
+func writeExampleComparison(b *bytes.Buffer, label string, examples []namedExample) bool {
+	if len(examples) < 2 {
+		return false
+	}
+	keys := map[string]bool{}
+	for _, ex := range examples {
+		obj, ok := ex.Value.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range obj {
+			switch v.(type) {
+			case map[string]any, []any:
+				return false
+			}
+			keys[k] = true
+		}
+	}
+	fmt.Fprintf(b, "%s\n\n| Key |", label)
+	for _, ex := range examples {
+		fmt.Fprintf(b, " %s |", tableCell(nonEmpty(strings.TrimSpace(ex.Summary), ex.Name)))
+	}
+	b.WriteString("\n|---|" + strings.Repeat("---|", len(examples)) + "\n")
+	for _, k := range sortedKeys(keys) {
+		fmt.Fprintf(b, "| %s |", tableCell(inlineCode(k)))
+		for _, ex := range examples {
+			cell := "-"
+			if v, ok := ex.Value.(map[string]any)[k]; ok {
+				if data, err := json.Marshal(v); err == nil {
+					cell = tableCell(inlineCode(string(data)))
+				}
+			}
+			fmt.Fprintf(b, " %s |", cell)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return true
+}
+
+// unresolvedRefNote follows the label of an example whose value holds a
+// $ref object. Example values are literal, so the ref is shown as written.
+const unresolvedRefNote = "(contains unresolved $ref)"
+
+// containsRef reports whether an example value holds an object with a
+// string "$ref" member at any depth.
+func containsRef(v any) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		if _, ok := v["$ref"].(string); ok {
+			return true
+		}
+		for _, e := range v {
+			if containsRef(e) {
+				return true
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if containsRef(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// codeSample is one entry of an x-codeSamples style extension.
+type codeSample struct {
+	Lang   string
+	Label  string
+	Source string
+}
+
+// codeSamples returns the entries of the extension named key in exts
+// (DefaultCodeSamplesKey when key is empty), in order. Entries without a
+// source are skipped.
+func codeSamples(exts map[string]any, key string) []codeSample {
+	if key == "" {
+		key = DefaultCodeSamplesKey
+	}
+	list, _ := exts[key].([]any)
+	var samples []codeSample
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var c codeSample
+		c.Lang, _ = m["lang"].(string)
+		c.Label, _ = m["label"].(string)
+		c.Source, _ = m["source"].(string)
+		c.Lang = strings.TrimSpace(c.Lang)
+		c.Label = strings.TrimSpace(c.Label)
+		c.Source = strings.Trim(c.Source, "\n")
+		if strings.TrimSpace(c.Source) == "" {
+			continue
+		}
+		samples = append(samples, c)
+	}
+	return samples
+}
+
+// codeSampleFenceLanguages maps x-codeSamples lang values that are not
+// usable as fence info strings to ones that are.
+var codeSampleFenceLanguages = map[string]string{
+	"c#":          "csharp",
+	"c++":         "cpp",
+	"node":        "javascript",
+	"node.js":     "javascript",
+	"objective-c": "objectivec",
+	"shell":       "sh",
+}
+
+// writeCodeSamples emits a "**Code Samples**" block for the code samples
+// extension named key in exts: each sample labeled with its label (or its
+// lang) and fenced in its lang. The fence grows when the source itself
+// contains a fence.
+func writeCodeSamples(b *bytes.Buffer, exts map[string]any, key string) {
+	samples := codeSamples(exts, key)
+	if len(samples) == 0 {
+		return
+	}
+	blankLine(b)
+	fmt.Fprintf(b, "**Code Samples**\n")
+	for _, c := range samples {
+		if label := nonEmpty(c.Label, c.Lang); label != "" {
+			fmt.Fprintf(b, "%s\n", label)
+		}
+		lang := strings.ToLower(c.Lang)
+		if l, ok := codeSampleFenceLanguages[lang]; ok {
+			lang = l
+		}
+		lang = strings.ReplaceAll(lang, " ", "")
+		fence := "```"
+		for strings.Contains(c.Source, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(b, "%s%s\n%s\n%s\n", fence, lang, c.Source, fence)
+	}
+}
+
+// writeOperationMarker writes the Options.OperationMarkers comment that lets
+// external tools find an operation, keyed on its operationId. Operations
+// without one are keyed on method and path alone.
+func writeOperationMarker(b *bytes.Buffer, operationID, method, path string) {
+	// "--" may not appear inside an HTML comment.
+	operationID = strings.ReplaceAll(operationID, "--", "-")
+	path = strings.ReplaceAll(path, "--", "-")
+	if operationID == "" {
+		fmt.Fprintf(b, "<!-- operation: %s %s -->\n", method, path)
+		return
+	}
+	fmt.Fprintf(b, "<!-- operation: %s (%s %s) -->\n", operationID, method, path)
+}
+
+// writeDirectionalRequired lists, for Options.SplitReadWrite, which required
+// properties apply to requests and which to responses: read-only properties
+// are never required in a request and write-only ones never in a response.
+// access reports whether a property is read-only and write-only. Nothing is
+// written when no required property is either, as both lists would match.
+func writeDirectionalRequired(b *bytes.Buffer, required []string, access func(name string) (readOnly, writeOnly bool)) {
+	var request, response []string
+	split := false
+	for _, pn := range sortedRequired(required) {
+		readOnly, writeOnly := access(pn)
+		split = split || readOnly || writeOnly
+		if !readOnly {
+			request = append(request, "`"+pn+"`")
+		}
+		if !writeOnly {
+			response = append(response, "`"+pn+"`")
+		}
+	}
+	if !split {
+		return
+	}
+	list := func(names []string) string {
+		if len(names) == 0 {
+			return "none"
+		}
+		return strings.Join(names, ", ")
+	}
+	fmt.Fprintf(b, "\n**Required by direction**\n- Request: %s\n- Response: %s\n", list(request), list(response))
+}
+
+// sortedRequired returns a sorted copy of a schema's required list.
+func sortedRequired(required []string) []string {
+	sorted := append([]string(nil), required...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// mediaTypeDeprecated reports whether a media type carries the extension
+// named by Options.DeprecatedMediaTypeKey with the value true.
+func mediaTypeDeprecated(exts map[string]any, opts Options) bool {
+	key := opts.DeprecatedMediaTypeKey
+	if key == "" {
+		key = DefaultDeprecatedMediaTypeKey
+	}
+	dep, _ := exts[key].(bool)
+	return dep
+}
+
+// resolveServerURL substitutes each server variable in s.URL with its default,
+// or its first enum value when it has no default, giving a concrete URL for
+// examples. Variables with neither are left as placeholders and returned, in
+// sorted order, so callers can note them.
+func resolveServerURL(s *openapi3.Server) (string, []string) {
+	u := s.URL
+	var unresolved []string
+	for _, name := range sortedKeys(s.Variables) {
+		v := s.Variables[name]
+		value := ""
+		if v != nil {
+			value = v.Default
+			if value == "" && len(v.Enum) > 0 {
+				value = v.Enum[0]
+			}
+		}
+		if value == "" {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		u = strings.ReplaceAll(u, "{"+name+"}", value)
+	}
+	return u, unresolved
+}
+
+// deprecationReasonKey is the extension that says why an item is deprecated.
+const deprecationReasonKey = "x-deprecated-reason"
+
+// deprecatedMarker finds the "Deprecated: <reason>" convention in a
+// description, capturing the rest of its line.
+var deprecatedMarker = regexp.MustCompile(`(?i)\bdeprecated:[ \t]*([^\n]*)`)
+
+// deprecationReason returns why a deprecated item is deprecated: its
+// x-deprecated-reason extension, else the text after a "Deprecated:" marker
+// in its description, without a trailing period. It is empty when neither
+// gives a reason.
+func deprecationReason(description string, exts map[string]any) string {
+	reason, _ := exts[deprecationReasonKey].(string)
+	if reason = strings.TrimSpace(reason); reason == "" {
+		if m := deprecatedMarker.FindStringSubmatch(description); m != nil {
+			reason = strings.TrimSpace(m[1])
+		}
+	}
+	return strings.TrimSpace(strings.TrimSuffix(reason, "."))
+}
+
+// writeDeprecationBadge writes the bold line that opens a deprecated
+// operation, e.g. "**DEPRECATED — use /v2/pets instead**".
+func writeDeprecationBadge(b *bytes.Buffer, reason string) {
+	if reason != "" {
+		fmt.Fprintf(b, "**DEPRECATED — %s**\n\n", reason)
+		return
+	}
+	b.WriteString("**DEPRECATED**\n\n")
+}
+
+// deprecatedNote is the marker that follows a deprecated property's type,
+// with the reason when there is one.
+func deprecatedNote(reason string) string {
+	if reason != "" {
+		return " (deprecated — " + reason + ")"
+	}
+	return " (deprecated)"
+}
+
+// writeDescriptionBlock writes a multi-line API description, often Markdown
+// with paragraphs and lists, as is above the Overview bullets, followed by a
+// blank line. A single-line one is left to writeDescriptionBullet.
+func writeDescriptionBlock(b *bytes.Buffer, desc string) {
+	if strings.Contains(desc, "\n") {
+		fmt.Fprintf(b, "%s\n\n", desc)
+	}
+}
+
+// writeDescriptionBullet writes a single-line API description as the
+// Overview's "- Description:" bullet.
+func writeDescriptionBullet(b *bytes.Buffer, desc string) {
+	if desc != "" && !strings.Contains(desc, "\n") {
+		fmt.Fprintf(b, "- Description: %s\n", desc)
+	}
+}
+
+// noMatchingMediaTypes replaces the media types of a body or response when
+// Options.MediaTypeFilter removes all of them.
+const noMatchingMediaTypes = "(no matching media types)"
+
+// mediaTypeSelected reports whether mt passes opts.MediaTypeFilter.
+func mediaTypeSelected(mt string, opts Options) bool {
+	if opts.MediaTypeFilter == nil {
+		return true
+	}
+	base, _, _ := strings.Cut(mt, ";")
+	for _, want := range opts.MediaTypeFilter {
+		if strings.EqualFold(strings.TrimSpace(base), strings.TrimSpace(want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedMediaTypes returns the media types of mts that pass
+// opts.MediaTypeFilter, in order.
+func selectedMediaTypes(mts []string, opts Options) []string {
+	if opts.MediaTypeFilter == nil {
+		return mts
+	}
+	var selected []string
+	for _, mt := range mts {
+		if mediaTypeSelected(mt, opts) {
+			selected = append(selected, mt)
+		}
+	}
+	return selected
+}
+
+// writeOperationIntro writes what follows an operation heading in both
+// writers: the summary in bold, the description paragraph, then the
+// operation ID, each as its own block.
+func writeOperationIntro(b *bytes.Buffer, summary, description, operationID string) {
+	if summary = strings.TrimSpace(summary); summary != "" {
+		fmt.Fprintf(b, "**%s**\n", summary)
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		blankLine(b)
+		fmt.Fprintf(b, "%s\n", description)
+	}
+	if operationID != "" {
+		blankLine(b)
+		fmt.Fprintf(b, "_Operation ID_: `%s`\n", operationID)
+	}
+}
+
+// blankLine ends the current block with exactly one empty line, so blocks
+// written one after another are separated the same way whatever the
+// previous block left behind.
+func blankLine(b *bytes.Buffer) {
+	switch data := b.Bytes(); {
+	case len(data) == 0 || bytes.HasSuffix(data, []byte("\n\n")):
+	case data[len(data)-1] == '\n':
+		b.WriteByte('\n')
+	default:
+		b.WriteString("\n\n")
+	}
+}
+
+// blankLineUnlessHeading is blankLine, except directly under a heading,
+// where schema notes and lists start without one, like the Properties list.
+func blankLineUnlessHeading(b *bytes.Buffer) {
+	data := bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+	if !bytes.HasPrefix(data[bytes.LastIndexByte(data, '\n')+1:], []byte("#")) {
+		blankLine(b)
+	}
+}
+
+// writeEmptySection writes a section heading with a "None defined"
+// placeholder, or nothing when Options.OmitEmptySections is set.
+func writeEmptySection(b *bytes.Buffer, title string, opts Options) {
+	if opts.OmitEmptySections {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n- None defined\n", title)
+}
@@ -0,0 +1,30 @@
+package markdown
+
+import "strings"
+
+// Post-processing: re-indent nested list items.
+
+// indentLists rewrites the nesting of list items in md from the
+// DefaultIndent spaces per level the renderers write to width spaces per
+// level, so deeper items keep their relative depth. Lines inside fenced code
+// blocks and lines that are not list items are copied verbatim.
+func indentLists(md string, width int) string {
+	if width == DefaultIndent {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		depth := (len(line) - len(trimmed)) / DefaultIndent
+		if inFence || depth == 0 || listMarker(trimmed) == "" {
+			continue
+		}
+		lines[i] = strings.Repeat(" ", depth*width) + trimmed
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,117 @@
+package markdown
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// Public surface: with Options.ExcludeInternal, operations and schemas
+// flagged by the internal extension are left out of every section, along
+// with schemas only internal operations use.
+
+// internal reports whether any of exts flags its object as internal under
+// o.ExcludeInternal.
+func (o Options) internal(exts ...map[string]any) bool {
+	if !o.ExcludeInternal {
+		return false
+	}
+	key := o.InternalKey
+	if key == "" {
+		key = DefaultInternalKey
+	}
+	for _, e := range exts {
+		if v, _ := e[key].(bool); v {
+			return true
+		}
+	}
+	return false
+}
+
+// publicNames returns the names in all that are neither flagged internal nor
+// reachable only from internal operations. internalOps and publicOps hold
+// what the internal and public operations reach.
+func publicNames(all []string, flagged func(name string) bool, internalOps, publicOps refSet) map[string]bool {
+	public := map[string]bool{}
+	for _, name := range all {
+		_, internalUse := internalOps[name]
+		_, publicUse := publicOps[name]
+		if !flagged(name) && (publicUse || !internalUse) {
+			public[name] = true
+		}
+	}
+	return public
+}
+
+// publicSchemasOpenAPI3 returns schemas without the internal ones and those
+// only the internal operations of doc use.
+func publicSchemasOpenAPI3(doc *openapi3.T, schemas openapi3.Schemas, opts Options) openapi3.Schemas {
+	all := opts
+	all.ExcludeInternal = false
+	internalOps, publicOps := refSet{}, refSet{}
+	for _, ref := range indexOpenAPI3Operations(doc, all) {
+		if opts.internal(ref.PathItem.Extensions, ref.Op.Extensions) {
+			internalOps.addOperationOpenAPI3(ref.PathItem, ref.Op)
+		} else {
+			publicOps.addOperationOpenAPI3(ref.PathItem, ref.Op)
+		}
+	}
+	public := publicNames(sortedKeys(schemas), func(name string) bool {
+		ref := schemas[name]
+		return ref != nil && ref.Value != nil && opts.internal(ref.Value.Extensions)
+	}, internalOps, publicOps)
+	kept := make(openapi3.Schemas, len(public))
+	for name := range public {
+		kept[name] = schemas[name]
+	}
+	return kept
+}
+
+// publicDefinitionsSwagger2 is publicSchemasOpenAPI3 for the definitions of
+// a Swagger 2.0 document.
+func publicDefinitionsSwagger2(s *spec.Swagger, opts Options) spec.Definitions {
+	all := opts
+	all.ExcludeInternal = false
+	internalOps, publicOps := refSet{}, refSet{}
+	for _, ref := range indexSwagger2Operations(s, all) {
+		if opts.internal(ref.PathItem.Extensions, ref.Op.Extensions) {
+			internalOps.addOperationSwagger2(ref.PathItem, ref.Op, s.Definitions)
+		} else {
+			publicOps.addOperationSwagger2(ref.PathItem, ref.Op, s.Definitions)
+		}
+	}
+	public := publicNames(sortedKeys(s.Definitions), func(name string) bool {
+		return opts.internal(s.Definitions[name].Extensions)
+	}, internalOps, publicOps)
+	kept := make(spec.Definitions, len(public))
+	for name := range public {
+		kept[name] = s.Definitions[name]
+	}
+	return kept
+}
+
+// renderedComponentsOpenAPI3 returns the components of doc that are rendered:
+// never nil, and without the non-public schemas under Options.ExcludeInternal.
+func renderedComponentsOpenAPI3(doc *openapi3.T, opts Options) *openapi3.Components {
+	// Components is optional; documents built in code often leave it nil.
+	components := doc.Components
+	if components == nil {
+		components = &openapi3.Components{}
+	}
+	if opts.ExcludeInternal {
+		public := *components
+		public.Schemas = publicSchemasOpenAPI3(doc, components.Schemas, opts)
+		components = &public
+	}
+	return components
+}
+
+// renderedSwagger2 returns s, or under Options.ExcludeInternal a copy of it
+// with only the public definitions.
+func renderedSwagger2(s *spec.Swagger, opts Options) *spec.Swagger {
+	if !opts.ExcludeInternal {
+		return s
+	}
+	public := *s
+	public.Definitions = publicDefinitionsSwagger2(s, opts)
+	return &public
+}
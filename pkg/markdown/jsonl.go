@@ -0,0 +1,177 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// jsonlRecord is one line of OutputJSONL. Field order is the struct order
+// and every field but Example is always present, so indexers see a fixed
+// shape. Example is only set under Options.IndexIncludeFirstExample.
+type jsonlRecord struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	OperationID string   `json:"operationId"`
+	Tags        []string `json:"tags"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Anchor      string   `json:"anchor"`
+	Example     string   `json:"example,omitempty"`
+}
+
+// indexExampleLimit is the number of runes of a request example kept in an
+// index row; longer examples are cut and end in "…".
+const indexExampleLimit = 120
+
+// jsonlRenderer is the built-in OutputJSONL Renderer: one JSON object per
+// operation, in the order the Markdown output lists them. Anchor is the
+// operation heading's anchor in the Markdown output under opts.SlugStyle.
+// The document is not validated; the index only reads operation metadata.
+type jsonlRenderer struct{}
+
+func (jsonlRenderer) RenderOpenAPI3(doc *openapi3.T, raw []byte, opts Options) (string, error) {
+	if doc == nil {
+		return "", fmt.Errorf("render openapi 3: nil document")
+	}
+	defer phaseTimer(opts, PhaseRender)()
+	var records []jsonlRecord
+	for _, ref := range indexOpenAPI3Operations(doc, opts) {
+		if err := opts.canceled(); err != nil {
+			return "", err
+		}
+		r := jsonlRecord{
+			Method:      ref.Method,
+			Path:        ref.Path,
+			OperationID: ref.Op.OperationID,
+			Tags:        ref.Op.Tags,
+			Summary:     ref.Op.Summary,
+			Description: ref.Op.Description,
+		}
+		if opts.IndexIncludeFirstExample {
+			r.Example = indexExample(firstRequestExampleOpenAPI3(ref.Op))
+		}
+		records = append(records, r)
+	}
+	return writeJSONL(records, opts)
+}
+
+func (jsonlRenderer) RenderSwagger2(doc *spec.Swagger, raw []byte, opts Options) (string, error) {
+	if doc == nil {
+		return "", fmt.Errorf("render swagger 2.0: nil document")
+	}
+	defer phaseTimer(opts, PhaseRender)()
+	var records []jsonlRecord
+	for _, ref := range indexSwagger2Operations(doc, opts) {
+		if err := opts.canceled(); err != nil {
+			return "", err
+		}
+		r := jsonlRecord{
+			Method:      ref.Method,
+			Path:        ref.Path,
+			OperationID: ref.Op.ID,
+			Tags:        ref.Op.Tags,
+			Summary:     ref.Op.Summary,
+			Description: ref.Op.Description,
+		}
+		if opts.IndexIncludeFirstExample {
+			r.Example = indexExample(firstRequestExampleSwagger2(ref.PathItem, ref.Op))
+		}
+		records = append(records, r)
+	}
+	return writeJSONL(records, opts)
+}
+
+// firstRequestExampleOpenAPI3 returns the first request body example of op,
+// taking media types in sorted order and, within one, the inline example
+// before named examples in sorted order. It returns nil when there is none.
+func firstRequestExampleOpenAPI3(op *openapi3.Operation) any {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+	content := op.RequestBody.Value.Content
+	for _, mt := range sortedKeys(content) {
+		media := content[mt]
+		if media == nil {
+			continue
+		}
+		if media.Example != nil {
+			return media.Example
+		}
+		for _, name := range sortedKeys(media.Examples) {
+			if ex := media.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+				return ex.Value.Value
+			}
+		}
+	}
+	return nil
+}
+
+// firstRequestExampleSwagger2 returns the example of op's body parameter,
+// or nil when there is none.
+func firstRequestExampleSwagger2(pi *spec.PathItem, op *spec.Operation) any {
+	params := mergedParametersSwagger2(pi, op)
+	for i := range params {
+		if params[i].In == "body" {
+			return bodyExampleSwagger2(&params[i])
+		}
+	}
+	return nil
+}
+
+// indexExample formats ex on a single line for an index row: structured
+// values as compact JSON, strings with whitespace runs collapsed, and the
+// result cut to indexExampleLimit runes.
+func indexExample(ex any) string {
+	if ex == nil {
+		return ""
+	}
+	var s string
+	switch v := ex.(type) {
+	case string:
+		s = v
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprintf("%v", v)
+		} else {
+			s = string(buf)
+		}
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > indexExampleLimit {
+		s = string(r[:indexExampleLimit-1]) + "…"
+	}
+	return s
+}
+
+// writeJSONL fills in each record's anchor and encodes one record per line.
+// encoding/json replaces invalid UTF-8 with U+FFFD, so every line is valid
+// UTF-8 JSON.
+func writeJSONL(records []jsonlRecord, opts Options) (string, error) {
+	slug, err := anchorFunc(opts)
+	if err != nil {
+		return "", err
+	}
+	if err := checkOperationSort(opts.SortOperationsBy); err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	anchors := operationAnchors(records, func(r jsonlRecord) (string, string, string) { return r.Method, r.Path, r.OperationID }, slug, opts)
+	for i, r := range records {
+		if r.Tags == nil {
+			r.Tags = []string{}
+		}
+		r.Anchor = "#" + anchors[i]
+		if err := enc.Encode(r); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,94 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Response links: each link of an OpenAPI 3 response, listed under it with
+// the operation it leads to.
+
+// linkTargetOpenAPI3 describes the operation a response link leads to: a link
+// to its heading for an operationId or a same-document operationRef, or
+// "operation in <ref>" for an operationRef into another file. Targets that
+// are not in operations are shown as given.
+func linkTargetOpenAPI3(l *openapi3.Link, operations []openAPI3Op) string {
+	if l.OperationID != "" {
+		for _, o := range operations {
+			if o.Op.OperationID == l.OperationID {
+				return operationLink(o)
+			}
+		}
+		return fmt.Sprintf("operation `%s`", l.OperationID)
+	}
+	ref := strings.TrimSpace(l.OperationRef)
+	if ref == "" {
+		return ""
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return fmt.Sprintf("operation in `%s`", ref)
+	}
+	if method, path, ok := operationPointer(ref); ok {
+		for _, o := range operations {
+			if o.Method == method && o.Path == path {
+				return operationLink(o)
+			}
+		}
+	}
+	return fmt.Sprintf("operation `%s`", ref)
+}
+
+// operationLink links "METHOD /path" to o's heading.
+func operationLink(o openAPI3Op) string {
+	return fmt.Sprintf("[%s %s](#%s)", o.Method, o.Path, o.Anchor)
+}
+
+// operationPointer splits a same-document operationRef such as
+// "#/paths/~1pets~1{id}/get" into its upper-case method and path.
+func operationPointer(ref string) (method, path string, ok bool) {
+	rest, ok := strings.CutPrefix(ref, "#/paths/")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, "/")
+	if i < 0 {
+		return "", "", false
+	}
+	path, err := url.PathUnescape(rest[:i])
+	if err != nil {
+		return "", "", false
+	}
+	path = strings.NewReplacer("~1", "/", "~0", "~").Replace(path)
+	return strings.ToUpper(rest[i+1:]), path, true
+}
+
+// writeLinksOpenAPI3 lists a response's links, in name order, each with its
+// target, parameters, and description.
+func writeLinksOpenAPI3(b *bytes.Buffer, links openapi3.Links, target func(*openapi3.Link) string) {
+	for _, name := range sortedKeys(links) {
+		lr := links[name]
+		if lr == nil || lr.Value == nil {
+			continue
+		}
+		l := lr.Value
+		fmt.Fprintf(b, "  - Link `%s`", name)
+		if t := target(l); t != "" {
+			fmt.Fprintf(b, " → %s", t)
+		}
+		if len(l.Parameters) > 0 {
+			params := make([]string, 0, len(l.Parameters))
+			for _, pn := range sortedKeys(l.Parameters) {
+				params = append(params, fmt.Sprintf("%s: `%v`", pn, l.Parameters[pn]))
+			}
+			fmt.Fprintf(b, " (%s)", strings.Join(params, ", "))
+		}
+		if desc := strings.TrimSpace(l.Description); desc != "" {
+			fmt.Fprintf(b, " — %s", desc)
+		}
+		b.WriteByte('\n')
+	}
+}
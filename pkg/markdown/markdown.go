@@ -2,10 +2,21 @@
 package markdown
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,10 +33,441 @@ const (
 	FormatYAML InputFormat = "yaml"
 )
 
+// SlugStyle selects how heading anchors are generated, so that links into the
+// generated document resolve on the platform that renders it. The zero value
+// behaves like SlugGitHub.
+type SlugStyle string
+
+const (
+	// SlugGitHub matches GitHub: lowercased, punctuation other than '-' and
+	// '_' removed, and each space replaced by a hyphen.
+	SlugGitHub SlugStyle = "github"
+	// SlugGitLab matches GitLab: like SlugGitHub, but runs of hyphens are
+	// collapsed into one.
+	SlugGitLab SlugStyle = "gitlab"
+	// SlugMkDocs matches the Python-Markdown toc extension used by MkDocs:
+	// non-ASCII characters are dropped, and runs of spaces and hyphens become
+	// a single hyphen.
+	SlugMkDocs SlugStyle = "mkdocs"
+)
+
+// OperationSort selects the order operations are listed in, within each
+// tag and in the JSON Lines index. The zero value behaves like SortByPath.
+type OperationSort string
+
+const (
+	// SortByPath lists operations by path, and by HTTP method (GET, POST,
+	// PUT, DELETE, PATCH, OPTIONS, HEAD, TRACE) within a path.
+	SortByPath OperationSort = "path"
+	// SortByMethod lists operations by HTTP method, in the same method
+	// order, and by path within a method.
+	SortByMethod OperationSort = "method"
+	// SortByOperationID lists operations by operationId. Operations without
+	// one come last, in path order.
+	SortByOperationID OperationSort = "operationId"
+)
+
+// LineEnding selects the line terminator of the generated output. The zero
+// value behaves like LineEndingLF.
+type LineEnding string
+
+const (
+	// LineEndingLF terminates lines with "\n".
+	LineEndingLF LineEnding = "lf"
+	// LineEndingCRLF terminates lines with "\r\n", as Windows tools expect.
+	LineEndingCRLF LineEnding = "crlf"
+)
+
 // Options tune how ToMarkdown parses and validates the input spec.
 type Options struct {
 	Format         InputFormat
 	SkipValidation bool
+
+	// InputEncoding names the text encoding of the input (EncodingUTF8,
+	// EncodingUTF16, EncodingUTF16LE, EncodingUTF16BE, or EncodingLatin1).
+	// Empty means UTF-8; a UTF-16 byte order mark is honored either way.
+	InputEncoding string
+
+	// DocumentIndex picks the spec from a YAML input holding several
+	// "---"-separated documents, counting from 1. Zero uses the only
+	// document, or among several the only one with an "openapi" or
+	// "swagger" key; none or several such documents is an error.
+	DocumentIndex int
+
+	// TagModelIndex lists, under each tag heading, the named schemas the
+	// tag's operations reference (directly or transitively), linked to their
+	// entries in the Schemas section.
+	TagModelIndex bool
+	// SchemaUsageBackrefs adds a "**Used by**" list under each schema,
+	// linking to the operations whose parameters, request bodies, or
+	// responses reference it, directly or through other schemas. It is the
+	// inverse of TagModelIndex.
+	SchemaUsageBackrefs bool
+
+	// ExcludeInternal leaves operations (or whole path items) and schemas
+	// flagged internal by the extension named InternalKey out of every
+	// section, along with schemas that only internal operations use, for a
+	// public-facing rendering of an internal spec.
+	ExcludeInternal bool
+	// InternalKey overrides the extension read for ExcludeInternal. Empty
+	// means DefaultInternalKey.
+	InternalKey string
+
+	// Methods restricts rendering to operations with these HTTP methods
+	// (case-insensitive) in every section. Nil renders all methods.
+	Methods []string
+
+	// MediaTypeFilter restricts the request and response media types
+	// rendered to these (case-insensitive, parameters such as charset
+	// ignored), e.g. []string{"application/json"} for JSON-only docs. A body
+	// or response with none of them notes "(no matching media types)". Nil
+	// renders every media type.
+	MediaTypeFilter []string
+
+	// OmitSchemas drops the Schemas section, for endpoint references whose
+	// models are documented elsewhere. Links that would point into it (such
+	// as the TagModelIndex entries) are rendered as plain names.
+	OmitSchemas bool
+
+	// OperationMarkers emits an HTML comment such as
+	// "<!-- operation: getPets (GET /pets) -->" before each operation heading
+	// so doc-stitching tools can locate and replace individual operations.
+	OperationMarkers bool
+
+	// ReusableParameters and ReusableRequestBodies add "## Reusable
+	// Parameters" and "## Reusable Request Bodies" sections (OpenAPI 3)
+	// listing components.parameters and components.requestBodies, and mark
+	// operations that use them as "(shared: ...)" with a link to the entry.
+	ReusableParameters    bool
+	ReusableRequestBodies bool
+
+	// OmitEmptySections leaves out the headings of sections with nothing to
+	// list (Authentication, Servers, Tags, Endpoints by Tag, Examples)
+	// instead of rendering them with a "None defined" placeholder.
+	OmitEmptySections bool
+
+	// ExamplesSection adds a trailing "## Examples" index listing the
+	// responses that carry inline examples. The examples themselves are
+	// always rendered under their operations.
+	ExamplesSection bool
+
+	// ExampleComparisonTable renders the named examples of an OpenAPI 3
+	// media type side by side, as a table with one column per example and
+	// one row per top-level key, when there are several and all are flat
+	// JSON objects. Otherwise each keeps its own fence.
+	ExampleComparisonTable bool
+
+	// IndexIncludeFirstExample adds an "example" field to each OutputJSONL
+	// row holding the operation's first request example on one line,
+	// truncated. Off by default to keep the index compact.
+	IndexIncludeFirstExample bool
+
+	// RequiredHeadersSummary adds a "**Required headers:**" line to each
+	// operation, listing the headers implied by the apiKey-in-header
+	// security schemes that apply to it plus its required header
+	// parameters.
+	RequiredHeadersSummary bool
+
+	// PrimaryTagOnly renders an operation with several tags in full only
+	// under its first tag; the other tags list it under "**Also in this
+	// tag**" with a link to that rendering.
+	PrimaryTagOnly bool
+
+	// GroupParametersByLocation lists an operation's parameters under
+	// "**Path Parameters**", "**Query Parameters**", "**Header Parameters**",
+	// "**Cookie Parameters**", and (Swagger 2.0) "**Form Parameters**"
+	// headings instead of one "**Parameters**" list.
+	GroupParametersByLocation bool
+
+	// GroupDeprecatedParameters moves an operation's deprecated parameters
+	// (Swagger 2.0: x-deprecated) out of its parameter list into a
+	// "**Deprecated Parameters**" list after it.
+	GroupDeprecatedParameters bool
+
+	// InlineRequiredRecap appends "(required: id, name)" to each OpenAPI 3
+	// request and response media type whose schema is an object with
+	// required properties, so mandatory fields show without opening the
+	// schema section.
+	InlineRequiredRecap bool
+
+	// IncludeOperationServers lists an OpenAPI 3 operation's server
+	// overrides (its own, or else its path item's) under the operation, so
+	// readers of multi-host APIs see which host it is served from.
+	IncludeOperationServers bool
+
+	// IncludeRawSchema adds a collapsible block under each schema in the
+	// Schemas section holding that schema's JSON exactly as it appears in the
+	// input (after YAML is normalized to JSON).
+	IncludeRawSchema bool
+
+	// AppendRawSpec ends the document with an "## Appendix: Source Spec"
+	// section holding the spec as normalized JSON in a collapsible block,
+	// so the docs carry their machine-readable source.
+	AppendRawSpec bool
+	// MaxRawSpecBytes is the largest source AppendRawSpec includes; a larger
+	// one is replaced by a note. Zero means DefaultMaxRawSpecBytes.
+	MaxRawSpecBytes int
+
+	// IncludeBranding renders the ReDoc info.x-logo image above the
+	// document title, linked to its "href" when one is given.
+	IncludeBranding bool
+
+	// EmitModelDiagram adds a Mermaid classDiagram at the top of the
+	// Schemas section: each schema with its properties as fields, and an
+	// association for each property that refers to another schema.
+	EmitModelDiagram bool
+	// ModelDiagramMaxNodes caps the schemas drawn by EmitModelDiagram; the
+	// rest are left out with a note. Zero means DefaultModelDiagramMaxNodes.
+	ModelDiagramMaxNodes int
+
+	// IncludeChangelog renders a "## Changelog" section from the root
+	// extension named by ChangelogKey, when the spec carries one. Entries are
+	// objects with "version", "date", and "notes" (a string or a list of
+	// strings) and are listed newest-first by date.
+	IncludeChangelog bool
+	// ChangelogKey overrides the root extension read for IncludeChangelog.
+	// Empty means DefaultChangelogKey.
+	ChangelogKey string
+
+	// CodeSamplesKey overrides the operation extension rendered as
+	// "**Code Samples**": a list of {lang, label, source} objects, as in
+	// ReDoc. Empty means DefaultCodeSamplesKey.
+	CodeSamplesKey string
+
+	// ErrorSchemaName names a schema shared by error responses (e.g.
+	// "Error"). When it is set and the schema exists, a "## Errors" section
+	// documents its fields and lists the responses that return it, and those
+	// responses note "(see Errors)" instead of repeating the schema.
+	ErrorSchemaName string
+
+	// StrictValidation makes OpenAPI 3 validation errors fail the conversion
+	// instead of being ignored. It has no effect when SkipValidation is set.
+	StrictValidation bool
+
+	// PreferSchemaTitles uses an inline property schema's title as its type
+	// label in schema listings instead of appending "(title: ...)".
+	PreferSchemaTitles bool
+
+	// SplitReadWrite adds per-direction required lists under schemas whose
+	// required properties include readOnly or writeOnly ones: read-only
+	// properties are not required in requests, write-only ones not in
+	// responses.
+	SplitReadWrite bool
+
+	// EnumDescriptionKeys names the property extensions that pair enum values
+	// with descriptions, checked in order. When one is present the enum is
+	// rendered as a value/description sub-list. Empty means
+	// "x-enum-descriptions" then "x-enumDescriptions".
+	EnumDescriptionKeys []string
+	// DeprecatedEnumKey names the property extension listing enum values that
+	// are deprecated; those values are marked "(deprecated)" in the enum
+	// sub-list. Empty means DefaultDeprecatedEnumKey.
+	DeprecatedEnumKey string
+	// DeprecatedMediaTypeKey names the response media type extension that,
+	// when true, marks the media type "(deprecated)". Empty means
+	// DefaultDeprecatedMediaTypeKey.
+	DeprecatedMediaTypeKey string
+
+	// BaseDir is the directory a root-level "$ref" (a document that is only
+	// {"$ref": "other.yaml"}) is resolved against when RefFetcher is nil.
+	// Setting it also lets an OpenAPI 3 document refer to schemas and other
+	// components in sibling files, such as "./schemas/pet.yaml#/Pet", which
+	// are read from the file system relative to the document.
+	BaseDir string
+	// RefFetcher, when set, loads the document a root-level "$ref" points to.
+	// It receives the first ref exactly as written, and each ref of a
+	// chained indirection file resolved against the ref that led to it. It
+	// also loads the files an OpenAPI 3 document's external refs point to,
+	// resolved against the document's own ref. It takes precedence over
+	// BaseDir.
+	RefFetcher func(ref string) ([]byte, error)
+
+	// PrimaryServer is the index of the OpenAPI 3 server marked "(primary)"
+	// in the Servers lists, which still list every server. The marker is only
+	// shown when there are several servers. Defaults to the first server; an
+	// index out of range is an error.
+	PrimaryServer int
+	// PrimaryServerMatch, when set, selects instead the first server whose URL
+	// or description contains it (case-insensitive), e.g. "staging". When no
+	// server matches, the first server is primary.
+	PrimaryServerMatch string
+
+	// OnPhase, when set, is called with the duration of each conversion
+	// phase (PhaseNormalize, PhaseParse, PhaseValidate, PhaseRender) as it
+	// completes. Nothing is timed when it is nil.
+	OnPhase func(phase string, d time.Duration)
+
+	// DebugPanics adds the panic site and the goroutine stack to the error
+	// returned when a conversion panics, for bug reports. The error always
+	// names the phase that panicked.
+	DebugPanics bool
+
+	// OutputFormat selects the built-in renderer. Empty means OutputMarkdown.
+	OutputFormat OutputFormat
+	// Renderer, when set, replaces the renderer selected by OutputFormat.
+	Renderer Renderer
+
+	// LineEnding selects the output's line terminator. Renderers always
+	// write "\n"; the conversion happens once on the finished output. Empty
+	// means LineEndingLF.
+	LineEnding LineEnding
+
+	// SortOperationsBy orders operations within each tag. Empty means
+	// SortByPath.
+	SortOperationsBy OperationSort
+
+	// AnchorPrefix is prepended to every heading anchor that output links
+	// to (tags, operations, schemas, and reusable component entries) and
+	// to the links themselves, so several documents concatenated into one
+	// page keep distinct anchors. Those headings then get an explicit
+	// <a id> anchor. Empty leaves anchors to the Markdown renderer.
+	AnchorPrefix string
+
+	// PreferOperationIdAnchors anchors each operation heading at the slug
+	// of its operationId instead of "METHOD /path", so deep links survive
+	// path renames. Operations without an operationId keep the method and
+	// path, and repeated anchors get "-1", "-2", ... suffixes. Links to
+	// operations and OutputJSONL anchors follow.
+	PreferOperationIdAnchors bool
+
+	// MethodBadges prefixes each operation heading with a badge for its
+	// method, e.g. "#### 🟢 GET /pets", for skimming. Operation headings
+	// then get an explicit anchor, so links and anchors ignore the badge.
+	MethodBadges bool
+
+	// AnnotatePathParams writes each path placeholder in operation headings
+	// with its parameter's type, e.g. "/pets/{petId: integer}". Placeholders
+	// whose parameter or type is missing stay bare. Operation headings then
+	// get an explicit anchor, so anchors keep the plain path.
+	AnnotatePathParams bool
+
+	// MethodBadgeMap overrides the MethodBadges badge per upper-case method,
+	// e.g. {"GET": "[GET]"} for a monochrome rendering. Methods it does not
+	// list keep their default badge.
+	MethodBadgeMap map[string]string
+
+	// EmitAnchorsForSchemas gives each schema heading an explicit <a id>
+	// anchor even without an AnchorPrefix, so links to schemas resolve in
+	// renderers that do not derive anchors from headings.
+	EmitAnchorsForSchemas bool
+
+	// SlugStyle selects the anchor algorithm used for links to headings
+	// within the output. Empty means SlugGitHub.
+	SlugStyle SlugStyle
+
+	// WrapWidth hard-wraps prose lines (paragraphs and list items) at the
+	// given column on word boundaries. Headings, code fences, and table rows
+	// are left untouched. Zero disables wrapping.
+	WrapWidth int
+
+	// Indent is the number of spaces per level for nested list items, such
+	// as the media types under a response. Zero means DefaultIndent.
+	Indent int
+
+	// ctx is the context of a ToMarkdownContext or ConvertAllContext call,
+	// or nil. Renderers check it between operations and schemas so an
+	// abandoned conversion stops early.
+	ctx context.Context
+	// location is where the converted document was read from when a root
+	// "$ref" led to it, as reported by resolveRootRef, or "".
+	location string
+}
+
+// methodAllowed reports whether operations with the given HTTP method are
+// rendered under o.Methods.
+func (o Options) methodAllowed(method string) bool {
+	if o.Methods == nil {
+		return true
+	}
+	for _, m := range o.Methods {
+		if strings.EqualFold(strings.TrimSpace(m), method) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultIndent is the nesting indentation used when Options.Indent is zero.
+const DefaultIndent = 2
+
+// DefaultInternalKey is the extension read for Options.ExcludeInternal when
+// Options.InternalKey is empty.
+const DefaultInternalKey = "x-internal"
+
+// DefaultChangelogKey is the root extension read for Options.IncludeChangelog
+// when Options.ChangelogKey is empty.
+const DefaultChangelogKey = "x-changelog"
+
+// DefaultCodeSamplesKey is the operation extension rendered as code samples
+// when Options.CodeSamplesKey is empty.
+const DefaultCodeSamplesKey = "x-codeSamples"
+
+// DefaultMaxRawSpecBytes is the source size limit for Options.AppendRawSpec
+// when Options.MaxRawSpecBytes is zero.
+const DefaultMaxRawSpecBytes = 1 << 20
+
+// DefaultModelDiagramMaxNodes is the number of schemas drawn by
+// Options.EmitModelDiagram when Options.ModelDiagramMaxNodes is zero.
+const DefaultModelDiagramMaxNodes = 30
+
+// DefaultDeprecatedEnumKey is the property extension read for deprecated
+// enum values when Options.DeprecatedEnumKey is empty.
+const DefaultDeprecatedEnumKey = "x-deprecated-enum"
+
+// DefaultDeprecatedMediaTypeKey is the response media type extension read
+// when Options.DeprecatedMediaTypeKey is empty.
+const DefaultDeprecatedMediaTypeKey = "x-deprecated"
+
+// Conversion phases reported to Options.OnPhase.
+const (
+	// PhaseNormalize covers YAML-to-JSON conversion and root $ref resolution.
+	PhaseNormalize = "normalize"
+	// PhaseParse covers decoding JSON into the OpenAPI/Swagger model.
+	PhaseParse = "parse"
+	// PhaseValidate covers OpenAPI 3 validation.
+	PhaseValidate = "validate"
+	// PhaseRender covers generating the output.
+	PhaseRender = "render"
+)
+
+// phaseTimer starts timing a phase and returns a func that reports it to
+// opts.OnPhase. It does no work when OnPhase is nil.
+func phaseTimer(opts Options, phase string) func() {
+	if opts.OnPhase == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { opts.OnPhase(phase, time.Since(start)) }
+}
+
+// conversionPanic turns a value recovered from a panic during phase into an
+// error. It must be called from the deferred function that recovered, so that
+// the panicking frames are still on the stack for opts.DebugPanics.
+func conversionPanic(kind, phase string, r any, opts Options) error {
+	if !opts.DebugPanics {
+		return fmt.Errorf("%s conversion panic during %s: %v", kind, phase, r)
+	}
+	return fmt.Errorf("%s conversion panic during %s: %v at %s\n\n%s", kind, phase, r, panicSite(), debug.Stack())
+}
+
+// panicSite returns "function (file:line)" for the frame that panicked: the
+// first non-runtime frame below runtime.gopanic.
+func panicSite() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	panicking := false
+	for {
+		f, more := frames.Next()
+		if panicking && !strings.HasPrefix(f.Function, "runtime.") {
+			return fmt.Sprintf("%s (%s:%d)", f.Function, filepath.Base(f.File), f.Line)
+		}
+		if f.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			return "unknown location"
+		}
+	}
 }
 
 type versionProbe struct {
@@ -37,44 +479,266 @@ type versionProbe struct {
 // - Detects version via top-level "swagger" (2.0) or "openapi" (3.x).
 // - Supports auto-detection of JSON vs YAML, overridable via Options.Format.
 func ToMarkdown(data []byte, opts Options) (string, error) {
-	jsonData, err := normalizeToJSON(data, opts.Format)
+	jsonData, opts, err := normalizeInput(data, opts)
 	if err != nil {
 		return "", err
 	}
 
+	md, err := convertJSON(jsonData, opts)
+	if err != nil {
+		return "", err
+	}
+	return postProcess(md, opts)
+}
+
+// ConvertAll renders data once per output format, parsing it only once.
+// Options.OutputFormat and Options.Renderer are ignored; each format selects
+// its built-in renderer. Unknown formats fail before any parsing.
+func ConvertAll(data []byte, opts Options, formats []OutputFormat) (map[OutputFormat]string, error) {
+	selected := make(map[OutputFormat]Renderer, len(formats))
+	for _, f := range formats {
+		r, err := selectRenderer(Options{OutputFormat: f})
+		if err != nil {
+			return nil, err
+		}
+		selected[f] = r
+	}
+
+	jsonData, opts, err := normalizeInput(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseJSON(jsonData, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.validate(opts); err != nil {
+		return nil, err
+	}
+
+	out := make(map[OutputFormat]string, len(selected))
+	for f, r := range selected {
+		if err := opts.canceled(); err != nil {
+			return nil, err
+		}
+		fopts := opts
+		fopts.OutputFormat, fopts.Renderer = f, nil
+		md, err := parsed.render(r, fopts)
+		if err != nil {
+			return nil, err
+		}
+		if out[f], err = postProcess(md, fopts); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ToMarkdownFromDoc converts an already-loaded OpenAPI 3.x document to Markdown,
+// skipping the serialize/parse round-trip. Options.Format is ignored; the
+// document is still validated unless Options.SkipValidation is set, whichever
+// renderer is selected.
+func ToMarkdownFromDoc(doc *openapi3.T, opts Options) (string, error) {
+	r, err := selectRenderer(opts)
+	if err != nil {
+		return "", err
+	}
+	if err := validateOpenAPI3(doc, opts); err != nil {
+		return "", err
+	}
+	md, err := r.RenderOpenAPI3(doc, nil, opts)
+	if err != nil {
+		return "", err
+	}
+	return postProcess(md, opts)
+}
+
+// ToMarkdownFromSwagger converts an already-decoded Swagger 2.0 document to
+// Markdown. Options.Format is ignored.
+func ToMarkdownFromSwagger(s *spec.Swagger, opts Options) (string, error) {
+	r, err := selectRenderer(opts)
+	if err != nil {
+		return "", err
+	}
+	md, err := r.RenderSwagger2(s, nil, opts)
+	if err != nil {
+		return "", err
+	}
+	return postProcess(md, opts)
+}
+
+// ToMarkdownFromMap converts a spec assembled as a Go map, as decoded by
+// encoding/json or yaml.v3, to Markdown. The map is marshaled to JSON and
+// runs through the normal pipeline; Options.Format and
+// Options.InputEncoding are ignored. The map must carry a "swagger" or
+// "openapi" version string.
+func ToMarkdownFromMap(m map[string]any, opts Options) (string, error) {
+	_, isSwagger := m["swagger"].(string)
+	_, isOpenAPI := m["openapi"].(string)
+	if !isSwagger && !isOpenAPI {
+		return "", fmt.Errorf("map is not an OpenAPI document: missing \"swagger\" or \"openapi\" version string")
+	}
+	jsonData, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode map as JSON: %w", err)
+	}
+	jsonData, opts.location, err = resolveRootRef(jsonData, opts)
+	if err != nil {
+		return "", err
+	}
+	md, err := convertJSON(jsonData, opts)
+	if err != nil {
+		return "", err
+	}
+	return postProcess(md, opts)
+}
+
+// Normalize converts a JSON or YAML spec to compact JSON, the form the
+// converter works from, without rendering it. A UTF-8 or UTF-16 byte order
+// mark is honored and dropped, YAML merge keys ("<<") are expanded, and of a
+// multi-document YAML input the spec document is picked as for a zero
+// Options.DocumentIndex. JSON input keeps its key order.
+func Normalize(data []byte, format InputFormat) ([]byte, error) {
+	data, err := decodeInput(data, "")
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := normalizeToJSON(data, format, 0)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, jsonData); err != nil {
+		return nil, fmt.Errorf("invalid JSON input: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// postProcess applies output-wide options to rendered output.
+func postProcess(md string, opts Options) (string, error) {
+	if opts.Indent < 0 {
+		return "", fmt.Errorf("invalid indent %d, must not be negative", opts.Indent)
+	}
+	if opts.Indent > 0 && (opts.OutputFormat == "" || opts.OutputFormat == OutputMarkdown) {
+		md = indentLists(md, opts.Indent)
+	}
+	if opts.WrapWidth > 0 && (opts.OutputFormat == "" || opts.OutputFormat == OutputMarkdown) {
+		md = wrapMarkdown(md, opts.WrapWidth)
+	}
+	switch opts.LineEnding {
+	case LineEndingLF, "":
+	case LineEndingCRLF:
+		// Line breaks copied from CRLF input are normalized first so
+		// they don't become "\r\r\n".
+		md = strings.ReplaceAll(strings.ReplaceAll(md, "\r\n", "\n"), "\n", "\r\n")
+	default:
+		return "", fmt.Errorf("unknown line ending %q, must be one of: lf,crlf", opts.LineEnding)
+	}
+	return md, nil
+}
+
+// normalizeInput decodes raw input to UTF-8, converts it to JSON, and follows
+// a root-level $ref. It returns opts with the location of the document the
+// ref led to, so the document's own relative refs resolve against it.
+func normalizeInput(data []byte, opts Options) ([]byte, Options, error) {
+	done := phaseTimer(opts, PhaseNormalize)
+	data, err := decodeInput(data, opts.InputEncoding)
+	if err != nil {
+		return nil, opts, err
+	}
+	jsonData, err := normalizeToJSON(data, opts.Format, opts.DocumentIndex)
+	if err != nil {
+		return nil, opts, err
+	}
+	jsonData, opts.location, err = resolveRootRef(jsonData, opts)
+	if err != nil {
+		return nil, opts, err
+	}
+	done()
+	return jsonData, opts, nil
+}
+
+// convertJSON parses normalized JSON input and renders it with the selected
+// renderer.
+func convertJSON(jsonData []byte, opts Options) (string, error) {
+	r, err := selectRenderer(opts)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := parseJSON(jsonData, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := parsed.validate(opts); err != nil {
+		return "", err
+	}
+	return parsed.render(r, opts)
+}
+
+// parsedSpec holds a decoded document of either version together with the
+// JSON it was decoded from.
+type parsedSpec struct {
+	openAPI3 *openapi3.T
+	swagger2 *spec.Swagger
+	raw      []byte
+}
+
+// validate validates an OpenAPI 3 document before any renderer sees it;
+// Swagger 2.0 documents have no validation step.
+func (p parsedSpec) validate(opts Options) error {
+	return validateOpenAPI3(p.openAPI3, opts)
+}
+
+func (p parsedSpec) render(r Renderer, opts Options) (string, error) {
+	if p.openAPI3 != nil {
+		return r.RenderOpenAPI3(p.openAPI3, p.raw, opts)
+	}
+	return r.RenderSwagger2(p.swagger2, p.raw, opts)
+}
+
+// parseJSON detects the spec version of normalized JSON input and decodes it
+// with the matching parser.
+func parseJSON(jsonData []byte, opts Options) (parsedSpec, error) {
 	var vp versionProbe
 	if err := json.Unmarshal(jsonData, &vp); err != nil {
-		return "", fmt.Errorf("failed to parse input as JSON: %w", err)
+		return parsedSpec{}, fmt.Errorf("failed to parse input as JSON: %w", err)
 	}
 
 	switch {
 	case strings.HasPrefix(vp.Swagger, "2.0"):
-		return swagger2ToMarkdown(jsonData)
+		s, err := parseSwagger2(jsonData, opts)
+		return parsedSpec{swagger2: s, raw: jsonData}, err
 	case strings.HasPrefix(vp.OpenAPI, "3."):
-		return openAPI3ToMarkdown(jsonData, opts)
+		doc, err := parseOpenAPI3(jsonData, opts)
+		return parsedSpec{openAPI3: doc, raw: jsonData}, err
 	default:
 		// Try 2.0 first, then 3.x as a fallback.
-		if md, err := swagger2ToMarkdown(jsonData); err == nil {
-			return md, nil
+		if s, err := parseSwagger2(jsonData, opts); err == nil {
+			return parsedSpec{swagger2: s, raw: jsonData}, nil
 		}
-		if md, err := openAPI3ToMarkdown(jsonData, opts); err == nil {
-			return md, nil
+		if doc, err := parseOpenAPI3(jsonData, opts); err == nil {
+			return parsedSpec{openAPI3: doc, raw: jsonData}, nil
 		}
-		return "", fmt.Errorf("could not detect or parse OpenAPI version (swagger=%q, openapi=%q)", vp.Swagger, vp.OpenAPI)
+		return parsedSpec{}, fmt.Errorf("could not detect or parse OpenAPI version (swagger=%q, openapi=%q)", vp.Swagger, vp.OpenAPI)
 	}
 }
 
 // normalizeToJSON ensures we always work with JSON for downstream parsing.
-func normalizeToJSON(data []byte, format InputFormat) ([]byte, error) {
+// docIndex picks the document of a multi-document YAML input, as
+// Options.DocumentIndex.
+func normalizeToJSON(data []byte, format InputFormat, docIndex int) ([]byte, error) {
+	if docIndex < 0 {
+		return nil, fmt.Errorf("invalid document index %d, must not be negative", docIndex)
+	}
 	// If the user specified a format, honor it.
 	if format == FormatJSON {
-		return data, nil
+		return jsonDocument(data, docIndex)
 	}
 
 	if format == FormatYAML {
-		var v any
-		if err := yaml.Unmarshal(data, &v); err != nil {
-			return nil, fmt.Errorf("failed to parse input as YAML: %w", err)
+		v, err := yamlDocument(data, docIndex)
+		if err != nil {
+			return nil, err
 		}
 		jsonData, err := json.Marshal(v)
 		if err != nil {
@@ -86,16 +750,79 @@ func normalizeToJSON(data []byte, format InputFormat) ([]byte, error) {
 	// Auto-detect: try JSON, then YAML.
 	var tmp any
 	if err := json.Unmarshal(data, &tmp); err == nil {
-		return data, nil
+		return jsonDocument(data, docIndex)
 	}
 
-	if err := yaml.Unmarshal(data, &tmp); err == nil {
+	if tmp, err := yamlDocument(data, docIndex); err == nil {
 		jsonData, err := json.Marshal(tmp)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert YAML to JSON: %w", err)
 		}
 		return jsonData, nil
+	} else if !errors.Is(err, errYAMLSyntax) {
+		return nil, err
 	}
 
 	return nil, fmt.Errorf("input is neither valid JSON nor YAML")
 }
+
+// jsonDocument returns JSON input unchanged; it holds a single document, so
+// only docIndex 0 or 1 selects it.
+func jsonDocument(data []byte, docIndex int) ([]byte, error) {
+	if docIndex > 1 {
+		return nil, fmt.Errorf("document index %d out of range: input has 1 document", docIndex)
+	}
+	return data, nil
+}
+
+// errYAMLSyntax marks yamlDocument errors caused by input that is not YAML.
+var errYAMLSyntax = errors.New("failed to parse input as YAML")
+
+// yamlDocument decodes the document of a possibly multi-document YAML
+// stream picked by docIndex (1-based). With docIndex 0, a single document is
+// used as is; among several, the one with an "openapi" or "swagger" key is,
+// and it is an error when none or more than one has one.
+func yamlDocument(data []byte, docIndex int) (any, error) {
+	var docs []any
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var v any
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", errYAMLSyntax, err)
+		}
+		docs = append(docs, v)
+	}
+	if docIndex > 0 {
+		if docIndex > len(docs) {
+			return nil, fmt.Errorf("document index %d out of range: input has %d YAML documents", docIndex, len(docs))
+		}
+		return docs[docIndex-1], nil
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
+	var specs []string
+	pick := -1
+	for i, d := range docs {
+		m, _ := d.(map[string]any)
+		_, isOpenAPI := m["openapi"]
+		_, isSwagger := m["swagger"]
+		if isOpenAPI || isSwagger {
+			specs = append(specs, strconv.Itoa(i+1))
+			pick = i
+		}
+	}
+	switch len(specs) {
+	case 1:
+		return docs[pick], nil
+	case 0:
+		return nil, fmt.Errorf("input has %d YAML documents and none has an \"openapi\" or \"swagger\" key; set Options.DocumentIndex to choose one", len(docs))
+	default:
+		return nil, fmt.Errorf("input has %d YAML documents and documents %s look like specs; set Options.DocumentIndex to choose one", len(docs), strings.Join(specs, ", "))
+	}
+}
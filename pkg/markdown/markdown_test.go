@@ -1,9 +1,21 @@
 package markdown
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
 )
 
 const minimalSwagger2JSON = `{
@@ -131,6 +143,9 @@ func TestToMarkdown_V2Fixture_JSON(t *testing.T) {
 	if !strings.Contains(md, "Mini Store API (v2)") {
 		t.Fatalf("expected markdown to mention v2 title, got: %s", md[:min(80, len(md))])
 	}
+	if !strings.Contains(md, "- API Version: 1.0.0\n- Swagger Version: 2.0\n") {
+		t.Fatalf("expected overview to list API and Swagger versions separately, got: %s", md[:min(200, len(md))])
+	}
 	// Verify that at least one response line shows a named schema from a $ref.
 	if !strings.Contains(md, "schema: PetList") {
 		t.Fatalf("expected markdown to mention PetList schema in a response, got: %s", md[:min(200, len(md))])
@@ -161,6 +176,9 @@ func TestToMarkdown_V3Fixture_JSON(t *testing.T) {
 	if !strings.Contains(md, "Mini Store API (v3)") {
 		t.Fatalf("expected markdown to mention v3 title, got: %s", md[:min(80, len(md))])
 	}
+	if !strings.Contains(md, "- API Version: 1.0.0\n- OpenAPI Version: 3.0.3\n") {
+		t.Fatalf("expected overview to list API and OpenAPI versions separately, got: %s", md[:min(200, len(md))])
+	}
 }
 
 func TestToMarkdown_V3Fixture_YAML(t *testing.T) {
@@ -179,6 +197,3283 @@ func TestToMarkdown_V3Fixture_YAML(t *testing.T) {
 	if !strings.Contains(md, "Mini Store API (v3)") {
 		t.Fatalf("expected markdown to mention v3 title, got: %s", md[:min(80, len(md))])
 	}
+	if !strings.Contains(md, "- API Version: 1.0.0\n- OpenAPI Version: 3.0.3\n") {
+		t.Fatalf("expected overview to list API and OpenAPI versions separately, got: %s", md[:min(200, len(md))])
+	}
+}
+
+func TestOpenAPI3_SharedResponse_Ref(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.shared-responses.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.shared-responses.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v3.shared-responses.json) returned error: %v", err)
+	}
+	if !strings.Contains(md, "- 404 (shared: NotFound) — The thing was not found") {
+		t.Fatalf("expected 404 response to note the shared NotFound response")
+	}
+	if !strings.Contains(md, "  - application/json — schema: $ref:Error") {
+		t.Fatalf("expected shared response content to render")
+	}
+	if strings.Contains(md, "- 200 (shared:") {
+		t.Fatalf("expected inline 200 response not to be marked as shared")
+	}
+}
+
+func TestObjectConstraints_PropertyCounts(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.object-constraints.json", "testdata/v3.object-constraints.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "### Labels\nFree-form labels.\n\n[properties: min=1, max=50]\n") {
+				t.Fatalf("expected Labels schema to render both property count bounds, got:\n%s", md)
+			}
+			if !strings.Contains(md, "### Settings\n[properties: max=10]\n") {
+				t.Fatalf("expected Settings schema to render only the max bound, got:\n%s", md)
+			}
+		})
+	}
+}
+
+// wrapSwagger2JSON has a long description and a long example string, so the
+// wrapped output can be checked both for wrapped prose and untouched fences.
+const wrapSwagger2JSON = `{
+  "swagger": "2.0",
+  "info": {
+    "title": "Wrap API",
+    "version": "1.0.0",
+    "description": "This description is deliberately long so that it has to be wrapped across several lines of output."
+  },
+  "paths": {},
+  "definitions": {
+    "Note": {
+      "type": "object",
+      "properties": { "text": { "type": "string" } },
+      "example": { "text": "an example value that is long enough to exceed the configured wrap width" }
+    }
+  }
+}`
+
+func TestToMarkdown_WrapWidth(t *testing.T) {
+	md, err := ToMarkdown([]byte(wrapSwagger2JSON), Options{Format: FormatJSON, WrapWidth: 40})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "- Description: This description is\n" +
+		"  deliberately long so that it has to be\n" +
+		"  wrapped across several lines of\n" +
+		"  output.\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected description to be wrapped at 40 columns, got:\n%s", md)
+	}
+	if !strings.Contains(md, `"text": "an example value that is long enough to exceed the configured wrap width"`) {
+		t.Fatalf("expected code fence content to be left unwrapped, got:\n%s", md)
+	}
+
+	unwrapped, err := ToMarkdown([]byte(wrapSwagger2JSON), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(unwrapped, "- Description: This description is deliberately long so that it has to be wrapped across several lines of output.\n") {
+		t.Fatalf("expected description to stay on one line when WrapWidth is 0")
+	}
+}
+
+func TestWrapKeepsBlockMarkersOffLineStarts(t *testing.T) {
+	got := wrapMarkdown("Returns all the pet records for owner123 - including owner details and the status", 40)
+	want := "Returns all the pet records for\n" +
+		"owner123 - including owner details and\n" +
+		"the status"
+	if got != want {
+		t.Fatalf("expected the dash to be carried down with the word before it, got:\n%s", got)
+	}
+
+	for _, marker := range []string{"#", "+", "*", "1.", "2)", ">", "---", "==="} {
+		// The second line is full just before the marker.
+		line := "- Description: Returns all the pet records for the owner with identifier1 " + marker + " including owner details"
+		for i, l := range strings.Split(wrapMarkdown(line, 40), "\n") {
+			if i > 0 && startsBlock(strings.Fields(l)[0]) {
+				t.Fatalf("marker %q: continuation line %q starts a new block", marker, l)
+			}
+		}
+	}
+}
+
+// indentOpenAPI3JSON has nested list items at two depths in the operation
+// description, and a code sample whose source looks like a nested list.
+const indentOpenAPI3JSON = `{
+  "openapi": "3.0.3",
+  "info": { "title": "Indent API", "version": "1.0.0" },
+  "paths": {
+    "/pets": {
+      "get": {
+        "description": "Filters:\n- by kind\n  - cats\n    - indoor",
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": { "application/json": { "schema": { "type": "string" } } }
+          }
+        },
+        "x-codeSamples": [{ "lang": "YAML", "source": "pets:\n  - cat" }]
+      }
+    }
+  }
+}`
+
+func TestToMarkdown_Indent(t *testing.T) {
+	md, err := ToMarkdown([]byte(indentOpenAPI3JSON), Options{Format: FormatJSON, Indent: 4})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- 200 — OK\n    - application/json — schema: string\n",
+		"- by kind\n    - cats\n        - indoor\n",
+		"```yaml\npets:\n  - cat\n```\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q with four-space nesting, got:\n%s", want, md)
+		}
+	}
+
+	def, err := ToMarkdown([]byte(indentOpenAPI3JSON), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(def, "- 200 — OK\n  - application/json — schema: string\n") {
+		t.Fatalf("expected two-space nesting by default, got:\n%s", def)
+	}
+
+	if _, err := ToMarkdown([]byte(indentOpenAPI3JSON), Options{Format: FormatJSON, Indent: -1}); err == nil {
+		t.Fatalf("expected an error for a negative indent")
+	}
+}
+
+func TestSecurityRequirements_AndOr(t *testing.T) {
+	cases := []struct {
+		fixture string
+		alt     string
+	}{
+		{"testdata/v2.security.json", "BasicAuth"},
+		{"testdata/v3.security.json", "BearerAuth"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(tc.fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+			}
+			wants := []string{
+				"- Default security: (ApiKeyAuth AND OAuth2 [things:read]) OR " + tc.alt + "\n",
+				"#### POST /things\n**Create a thing**\n\n**Security**\n- (ApiKeyAuth AND OAuth2 [things:read (read things), things:write (modify things)])\n",
+				"**Security**\n- None (public)\n",
+				"**Security**\n- " + tc.alt + " OR anonymous\n",
+			}
+			for _, want := range wants {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected markdown to contain %q, got:\n%s", want, md)
+				}
+			}
+			if strings.Count(md, "**Security**") != 3 {
+				t.Fatalf("expected only operations that override security to render it, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestOpenAPI3_NamedExamples_SummaryDescription(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.named-examples.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.named-examples.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v3.named-examples.json) returned error: %v", err)
+	}
+	wants := []string{
+		"Request example (express, application/json)\n_Express delivery_ — Ships the same day; incurs a surcharge.\n```json\n",
+		"Request example (plain, application/json)\n```json\n",
+		"Response example (created, 201, application/json)\n_A freshly created order_\n```json\n",
+	}
+	for _, want := range wants {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected markdown to contain %q, got:\n%s", want, md)
+		}
+	}
+}
+
+// invalidOpenAPI3JSON parses fine but fails validation: the response lacks
+// its required description.
+const invalidOpenAPI3JSON = `{
+  "openapi": "3.0.3",
+  "info": { "title": "Invalid API", "version": "1.0.0" },
+  "paths": {
+    "/ping": {
+      "get": {
+        "responses": { "200": {} }
+      }
+    }
+  },
+  "components": {}
+}`
+
+func TestOpenAPI3_ValidationModes(t *testing.T) {
+	if _, err := ToMarkdown([]byte(invalidOpenAPI3JSON), Options{Format: FormatJSON}); err != nil {
+		t.Fatalf("expected lenient default to ignore validation errors, got: %v", err)
+	}
+	if _, err := ToMarkdown([]byte(invalidOpenAPI3JSON), Options{Format: FormatJSON, SkipValidation: true, StrictValidation: true}); err != nil {
+		t.Fatalf("expected SkipValidation to take precedence over StrictValidation, got: %v", err)
+	}
+	_, err := ToMarkdown([]byte(invalidOpenAPI3JSON), Options{Format: FormatJSON, StrictValidation: true})
+	if err == nil || !strings.Contains(err.Error(), "validate openapi 3") {
+		t.Fatalf("expected StrictValidation to fail with a validation error, got: %v", err)
+	}
+
+	// Validation runs before any renderer is chosen, not inside the
+	// Markdown one.
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(invalidOpenAPI3JSON))
+	if err != nil {
+		t.Fatalf("LoadFromData returned error: %v", err)
+	}
+	for name, opts := range map[string]Options{
+		"jsonl":    {OutputFormat: OutputJSONL, StrictValidation: true},
+		"elements": {Renderer: Elements(outlineRenderer{}), StrictValidation: true},
+		"custom":   {Renderer: titleRenderer{}, StrictValidation: true},
+	} {
+		if _, err := ToMarkdown([]byte(invalidOpenAPI3JSON), opts); err == nil || !strings.Contains(err.Error(), "validate openapi 3") {
+			t.Fatalf("%s: expected ToMarkdown to fail with a validation error, got: %v", name, err)
+		}
+		if _, err := ToMarkdownFromDoc(doc, opts); err == nil || !strings.Contains(err.Error(), "validate openapi 3") {
+			t.Fatalf("%s: expected ToMarkdownFromDoc to fail with a validation error, got: %v", name, err)
+		}
+	}
+	if _, err := ConvertAll([]byte(invalidOpenAPI3JSON), Options{StrictValidation: true}, []OutputFormat{OutputJSONL}); err == nil {
+		t.Fatalf("expected ConvertAll to fail StrictValidation for JSONL output")
+	}
+}
+
+func TestTagModelIndex(t *testing.T) {
+	cases := []struct {
+		fixture string
+		pets    string
+	}{
+		{"testdata/v2.json", "**Models:** [NewPet](#newpet), [Owner](#owner), [Pet](#pet), [PetList](#petlist)\n"},
+		{"testdata/v3.json", "**Models:** [Error](#error), [NewPet](#newpet), [Owner](#owner), [Pet](#pet), [PetList](#petlist)\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(tc.fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, TagModelIndex: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+			}
+			// Owner is only reachable from pets operations through Pet.owner.
+			if !strings.Contains(md, "### pets\n"+tc.pets) {
+				t.Fatalf("expected pets tag to list its transitive models, got:\n%s", md)
+			}
+			if !strings.Contains(md, "### owners\n**Models:** [Owner](#owner)\n") {
+				t.Fatalf("expected owners tag to list only Owner, got:\n%s", md)
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+			}
+			if strings.Contains(plain, "**Models:**") {
+				t.Fatalf("expected no model index unless TagModelIndex is set")
+			}
+		})
+	}
+}
+
+// noComponentsOpenAPI3JSON is a valid document with no components section.
+const noComponentsOpenAPI3JSON = `{
+  "openapi": "3.0.3",
+  "info": { "title": "Loaded API", "version": "1.0.0" },
+  "paths": {
+    "/ping": {
+      "get": {
+        "summary": "Ping",
+        "responses": { "200": { "description": "ok" } }
+      }
+    }
+  }
+}`
+
+func TestToMarkdownFromDoc(t *testing.T) {
+	desc := "ok"
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: "In-Memory API", Version: "1.0.0"},
+		Paths: openapi3.NewPaths(openapi3.WithPath("/ping", &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				Summary:   "Ping",
+				Responses: openapi3.NewResponses(openapi3.WithStatus(200, &openapi3.ResponseRef{Value: &openapi3.Response{Description: &desc}})),
+			},
+		})),
+	}
+	md, err := ToMarkdownFromDoc(doc, Options{})
+	if err != nil {
+		t.Fatalf("ToMarkdownFromDoc returned error: %v", err)
+	}
+	if !strings.HasPrefix(md, "# In-Memory API\n") || !strings.Contains(md, "#### GET /ping\n**Ping**\n") {
+		t.Fatalf("expected in-memory document to render, got:\n%s", md)
+	}
+	if _, err := ToMarkdownFromDoc(nil, Options{}); err == nil {
+		t.Fatalf("expected error for nil document, got nil")
+	}
+
+	loaded, err := openapi3.NewLoader().LoadFromData([]byte(noComponentsOpenAPI3JSON))
+	if err != nil {
+		t.Fatalf("failed to load document: %v", err)
+	}
+	fromDoc, err := ToMarkdownFromDoc(loaded, Options{})
+	if err != nil {
+		t.Fatalf("ToMarkdownFromDoc returned error for a document without components: %v", err)
+	}
+	fromData, err := ToMarkdown([]byte(noComponentsOpenAPI3JSON), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if fromDoc != fromData {
+		t.Fatalf("expected ToMarkdownFromDoc to match ToMarkdown, got:\n%s\nwant:\n%s", fromDoc, fromData)
+	}
+}
+
+func TestToMarkdownFromMap(t *testing.T) {
+	m := map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": "Map API", "version": "1.0.0"},
+		"paths": map[string]any{
+			"/ping": map[string]any{
+				"get": map[string]any{
+					"summary":   "Ping",
+					"responses": map[string]any{"200": map[string]any{"description": "ok"}},
+				},
+			},
+		},
+	}
+	md, err := ToMarkdownFromMap(m, Options{})
+	if err != nil {
+		t.Fatalf("ToMarkdownFromMap returned error: %v", err)
+	}
+	if !strings.HasPrefix(md, "# Map API\n") || !strings.Contains(md, "#### GET /ping\n**Ping**\n") {
+		t.Fatalf("expected map document to render, got:\n%s", md)
+	}
+
+	_, err = ToMarkdownFromMap(map[string]any{"info": map[string]any{"title": "T"}}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "not an OpenAPI document") {
+		t.Fatalf("expected unrecognized map error, got %v", err)
+	}
+	if _, err := ToMarkdownFromMap(nil, Options{}); err == nil {
+		t.Fatalf("expected error for nil map, got nil")
+	}
+}
+
+func TestToMarkdownFromSwagger(t *testing.T) {
+	s := &spec.Swagger{SwaggerProps: spec.SwaggerProps{
+		Swagger: "2.0",
+		Info:    &spec.Info{InfoProps: spec.InfoProps{Title: "In-Memory API", Version: "1.0.0"}},
+		Paths: &spec.Paths{Paths: map[string]spec.PathItem{
+			"/ping": {PathItemProps: spec.PathItemProps{
+				Get: spec.NewOperation("ping").WithSummary("Ping").RespondsWith(200, spec.NewResponse().WithDescription("ok")),
+			}},
+		}},
+	}}
+	md, err := ToMarkdownFromSwagger(s, Options{})
+	if err != nil {
+		t.Fatalf("ToMarkdownFromSwagger returned error: %v", err)
+	}
+	if !strings.HasPrefix(md, "# In-Memory API\n") || !strings.Contains(md, "_Operation ID_: `ping`") {
+		t.Fatalf("expected in-memory document to render, got:\n%s", md)
+	}
+	if _, err := ToMarkdownFromSwagger(nil, Options{}); err == nil {
+		t.Fatalf("expected error for nil document, got nil")
+	}
+}
+
+func TestChangelogExtension(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.changelog.json", "testdata/v3.changelog.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, IncludeChangelog: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "## Changelog\n" +
+				"- 2.1.0 (2024-06-02)\n" +
+				"  - Added the /orders endpoints.\n" +
+				"  - Deprecated the legacy status field.\n" +
+				"- 2.0.0 (2024-02-15) — Switched to cursor pagination.\n" +
+				"- 1.0.0 (2023-01-10) — Initial release.\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected newest-first changelog, got:\n%s", md)
+			}
+
+			custom, err := ToMarkdown(data, Options{Format: FormatJSON, IncludeChangelog: true, ChangelogKey: "x-releases"})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(custom, "## Changelog\n- internal-7 (2024-07-01) — Internal build.\n") {
+				t.Fatalf("expected changelog from the configured key, got:\n%s", custom)
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(plain, "## Changelog") {
+				t.Fatalf("expected no changelog unless IncludeChangelog is set")
+			}
+		})
+	}
+}
+
+func TestNonStandardResponseCodes(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    string
+	}{
+		{"testdata/v2.odd-codes.json", "**Responses**\n" +
+			"- 200 — Padded success code\n" +
+			"- 404 — Not found\n" +
+			"- Success — Legacy success key\n" +
+			"- default — applies to all undocumented status codes — Unexpected error\n"},
+		{"testdata/v3.odd-codes.json", "**Responses**\n" +
+			"- 200 — Padded success code\n" +
+			"  - application/json (schema not specified)\n"},
+		{"testdata/v3.odd-codes.json", "- 404 — Not found\n" +
+			"- 5XX — Server error\n" +
+			"- Success — Legacy success key\n" +
+			"- default — applies to all undocumented status codes — Unexpected error\n"},
+		{"testdata/v3.odd-codes.json", "- GET /things 200 — has inline examples\n"},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, ExamplesSection: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if !strings.Contains(md, tc.want) {
+			t.Fatalf("%s: expected markdown to contain %q, got:\n%s", tc.fixture, tc.want, md)
+		}
+	}
+}
+
+func TestIncludeRawSchema(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.object-constraints.json", "testdata/v3.object-constraints.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, IncludeRawSchema: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "<details>\n<summary>Raw schema</summary>\n\n```json\n{\n" +
+				"  \"type\": \"object\",\n" +
+				"  \"maxProperties\": 10,\n" +
+				"  \"properties\": {\n" +
+				"    \"theme\": {\n" +
+				"      \"type\": \"string\"\n" +
+				"    }\n" +
+				"  }\n" +
+				"}\n```\n\n</details>\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected raw Settings schema in source key order, got:\n%s", md)
+			}
+			if strings.Count(md, "<summary>Raw schema</summary>") != 2 {
+				t.Fatalf("expected one raw block per schema, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestAllOf_MergesRequiredFields(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.allof-required.json", "testdata/v3.allof-required.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "### Extended\n**Properties**\n- `extra` (integer) (required)\n- `name` (string) (required)\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected Extended schema to list merged required properties, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestMatchesAndUnifiedDiff(t *testing.T) {
+	old := "# API\n\na\nb\nc\n"
+	if !Matches(old, strings.ReplaceAll(old, "\n", "\r\n")) {
+		t.Fatalf("expected CRLF and LF renderings to match")
+	}
+	if diff := UnifiedDiff("old.md", "new.md", old, old); diff != "" {
+		t.Fatalf("expected empty diff for identical input, got:\n%s", diff)
+	}
+
+	updated := "# API\n\na\nB\nc\n"
+	if Matches(old, updated) {
+		t.Fatalf("expected differing renderings not to match")
+	}
+	want := "--- old.md\n+++ new.md\n@@ -1,6 +1,6 @@\n # API\n \n a\n-b\n+B\n c\n \n"
+	if diff := UnifiedDiff("old.md", "new.md", old, updated); diff != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", diff, want)
+	}
+
+	want = "--- old.md\n+++ new.md\n@@ -0,0 +1,2 @@\n+x\n+\n"
+	if diff := UnifiedDiff("old.md", "new.md", "", "x\n"); diff != want {
+		t.Fatalf("unexpected diff against empty input:\n%s\nwant:\n%s", diff, want)
+	}
+}
+
+func TestSlugStyles(t *testing.T) {
+	cases := []struct {
+		heading                string
+		github, gitlab, mkdocs string
+	}{
+		{"Pet", "pet", "pet", "pet"},
+		{"Pet_Owner", "pet_owner", "pet_owner", "pet_owner"},
+		{"Pets - v2", "pets---v2", "pets-v2", "pets-v2"},
+		{"Café Order!", "café-order", "café-order", "caf-order"},
+	}
+	for _, tc := range cases {
+		for style, want := range map[SlugStyle]string{SlugGitHub: tc.github, SlugGitLab: tc.gitlab, SlugMkDocs: tc.mkdocs} {
+			slug, err := slugFunc(style)
+			if err != nil {
+				t.Fatalf("slugFunc(%q) returned error: %v", style, err)
+			}
+			if got := slug(tc.heading); got != want {
+				t.Fatalf("%s slug of %q = %q, want %q", style, tc.heading, got, want)
+			}
+		}
+	}
+
+	if _, err := ToMarkdown([]byte(minimalSwagger2JSON), Options{Format: FormatJSON, SlugStyle: "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown slug style, got nil")
+	}
+}
+
+func TestSwagger2_RequestBodySection(t *testing.T) {
+	data, err := os.ReadFile("testdata/v2.request-body.json")
+	if err != nil {
+		t.Fatalf("failed to read v2.request-body.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v2.request-body.json) returned error: %v", err)
+	}
+	if !strings.Contains(md, "**Parameters**\n- query `dryRun` (boolean) — Validate without placing the order.\n\n**Request Body**\n- `order` — schema: Order (required) — Order to place.\n") {
+		t.Fatalf("expected body parameter under its own Request Body section, got:\n%s", md)
+	}
+	if strings.Contains(md, "- body `order`") {
+		t.Fatalf("expected body parameter to be removed from the Parameters list, got:\n%s", md)
+	}
+}
+
+func TestRootRef(t *testing.T) {
+	data, err := os.ReadFile("testdata/root-ref.json")
+	if err != nil {
+		t.Fatalf("failed to read root-ref.json: %v", err)
+	}
+
+	if _, err := ToMarkdown(data, Options{}); err == nil || !strings.Contains(err.Error(), "cannot be resolved") {
+		t.Fatalf("expected unresolvable root $ref error without BaseDir, got %v", err)
+	}
+
+	md, err := ToMarkdown(data, Options{BaseDir: "testdata"})
+	if err != nil {
+		t.Fatalf("ToMarkdown(root-ref.json) returned error: %v", err)
+	}
+	if !strings.Contains(md, "Mini Store API (v3)") {
+		t.Fatalf("expected root $ref to render the referenced v3 fixture, got: %s", md[:min(80, len(md))])
+	}
+
+	var fetched string
+	fetcher := func(ref string) ([]byte, error) {
+		fetched = ref
+		return os.ReadFile("testdata/v2.json")
+	}
+	md, err = ToMarkdown(data, Options{BaseDir: "testdata", RefFetcher: fetcher})
+	if err != nil {
+		t.Fatalf("ToMarkdown(root-ref.json) with RefFetcher returned error: %v", err)
+	}
+	if fetched != "v3.json" || !strings.Contains(md, "Mini Store API (v2)") {
+		t.Fatalf("expected RefFetcher to take precedence over BaseDir (fetched %q), got: %s", fetched, md[:min(80, len(md))])
+	}
+
+	// In a chain, each ref resolves against the file that holds it.
+	dir := t.TempDir()
+	spec, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"root.json":             []byte(`{"$ref": "nested/mid.json"}`),
+		"nested/mid.json":       []byte(`{"$ref": "specs/api.json"}`),
+		"nested/specs/api.json": spec,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	md, err = ToMarkdown(files["root.json"], Options{BaseDir: dir})
+	if err != nil || !strings.Contains(md, "Mini Store API (v3)") {
+		t.Fatalf("expected a chained root $ref to resolve from each file's directory, got (%q, %v)", md[:min(80, len(md))], err)
+	}
+	var chain []string
+	fetcher = func(ref string) ([]byte, error) {
+		chain = append(chain, ref)
+		return files[ref], nil
+	}
+	if _, err := ToMarkdown(files["root.json"], Options{RefFetcher: fetcher}); err != nil {
+		t.Fatalf("ToMarkdown with a chained RefFetcher returned error: %v", err)
+	}
+	if strings.Join(chain, ",") != "nested/mid.json,nested/specs/api.json" {
+		t.Fatalf("expected fetched refs relative to the referring ref, got %v", chain)
+	}
+
+	// A document's own external refs go through RefFetcher too, resolved
+	// against the ref the document was fetched from.
+	files["nested/specs/refs.json"] = []byte(`{"openapi": "3.0.3", "info": {"title": "Refs", "version": "1"}, "paths": {},
+		"components": {"schemas": {"Pet": {"$ref": "schemas/pet.json#/Pet"}}}}`)
+	files["nested/specs/schemas/pet.json"] = []byte(`{"Pet": {"type": "object", "properties": {"nickname": {"type": "string"}}}}`)
+	chain = nil
+	md, err = ToMarkdown([]byte(`{"$ref": "nested/specs/refs.json"}`), Options{RefFetcher: fetcher})
+	if err != nil || !strings.Contains(md, "nickname") {
+		t.Fatalf("expected an external schema ref to load through RefFetcher, got (%q, %v)", md, err)
+	}
+	if strings.Join(chain, ",") != "nested/specs/refs.json,nested/specs/schemas/pet.json" {
+		t.Fatalf("expected the schema ref resolved against its document's ref, got %v", chain)
+	}
+
+	// A full document is not a root $ref, whatever its first key.
+	if _, ok := rootRef([]byte(`{"$ref": "a.json", "openapi": "3.0.3"}`)); ok {
+		t.Fatalf("expected a document with keys besides $ref not to be a root $ref")
+	}
+}
+
+func TestEnumDescriptions(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.enum-descriptions.json", "testdata/v3.enum-descriptions.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "- `status` (string)\n  - `placed` — Awaiting fulfilment.\n  - `shipped` — Handed to the carrier.\n  - `delivered` — Signed for by the customer.\n") {
+				t.Fatalf("expected status enum paired with x-enum-descriptions, got:\n%s", md)
+			}
+			if !strings.Contains(md, "- `priority` (integer)\n  - `1` — Ships same day.\n  - `2`\n") {
+				t.Fatalf("expected priority enum paired with x-enumDescriptions, got:\n%s", md)
+			}
+			if !strings.Contains(md, "- `channel` (string) [enum: \"web\", \"store\"]\n") {
+				t.Fatalf("expected channel enum without a known extension to stay inline, got:\n%s", md)
+			}
+
+			md, err = ToMarkdown(data, Options{Format: FormatJSON, EnumDescriptionKeys: []string{"x-values-help"}})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "- `channel` (string)\n  - `web` — Ordered online.\n  - `store` — Ordered in person.\n") {
+				t.Fatalf("expected channel enum paired with the configured extension, got:\n%s", md)
+			}
+			if !strings.Contains(md, "[enum: \"placed\", \"shipped\", \"delivered\"]") {
+				t.Fatalf("expected default extensions to be ignored when keys are configured, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestParameterFlags(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.parameter-flags.json", "testdata/v3.parameter-flags.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "- query `q` (string) (allows empty) — Search terms; an empty value lists everything.\n") {
+				t.Fatalf("expected q parameter to be marked as allowing empty values, got:\n%s", md)
+			}
+			if !strings.Contains(md, "- query `page` (integer) (deprecated)\n") {
+				t.Fatalf("expected page parameter to be marked deprecated, got:\n%s", md)
+			}
+		})
+	}
+}
+
+// titleRenderer is a stub Renderer that only emits the document title.
+type titleRenderer struct{}
+
+func (titleRenderer) RenderOpenAPI3(doc *openapi3.T, raw []byte, opts Options) (string, error) {
+	return "openapi3:" + doc.Info.Title, nil
+}
+
+func (titleRenderer) RenderSwagger2(doc *spec.Swagger, raw []byte, opts Options) (string, error) {
+	return "swagger2:" + doc.Info.Title, nil
+}
+
+func TestRendererSelection(t *testing.T) {
+	md, err := ToMarkdown([]byte(minimalSwagger2JSON), Options{Format: FormatJSON, OutputFormat: OutputMarkdown})
+	if err != nil {
+		t.Fatalf("ToMarkdown with OutputMarkdown returned error: %v", err)
+	}
+	if !strings.HasPrefix(md, "# Minimal API\n") {
+		t.Fatalf("expected Markdown output, got: %s", md[:min(80, len(md))])
+	}
+
+	if _, err := ToMarkdown([]byte(minimalSwagger2JSON), Options{Format: FormatJSON, OutputFormat: "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown output format, got nil")
+	}
+
+	md, err = ToMarkdown([]byte(minimalSwagger2JSON), Options{Format: FormatJSON, Renderer: titleRenderer{}})
+	if err != nil || md != "swagger2:Minimal API" {
+		t.Fatalf("custom Renderer for Swagger 2.0 = (%q, %v)", md, err)
+	}
+	data, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.json: %v", err)
+	}
+	md, err = ToMarkdown(data, Options{Format: FormatJSON, Renderer: titleRenderer{}})
+	if err != nil || md != "openapi3:Mini Store API (v3)" {
+		t.Fatalf("custom Renderer for OpenAPI 3 = (%q, %v)", md, err)
+	}
+}
+
+// outlineRenderer is a plain-text ElementRenderer: one line per element.
+type outlineRenderer struct{}
+
+func (outlineRenderer) Overview(w io.Writer, o OverviewElement) error {
+	_, err := fmt.Fprintf(w, "overview %s %s\n", o.Title, o.Version)
+	return err
+}
+
+func (outlineRenderer) Section(w io.Writer, s SectionElement) error {
+	_, err := fmt.Fprintf(w, "%s %s: %s\n", s.Kind, s.Name, s.Description)
+	return err
+}
+
+func (outlineRenderer) Operation(w io.Writer, op OperationElement) error {
+	_, err := fmt.Fprintf(w, "  %s %s #%s\n", op.Method, op.Path, op.Anchor)
+	return err
+}
+
+func (outlineRenderer) Schema(w io.Writer, s SchemaElement) error {
+	_, err := fmt.Fprintf(w, "  %s %v\n", s.Name, s.Properties)
+	return err
+}
+
+func TestElementRenderer(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.json", "testdata/v3.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			out, err := ToMarkdown(data, Options{Format: FormatJSON, Renderer: Elements(outlineRenderer{})})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "tag owners: Owner operations\n  GET /owners/{ownerId} #get-ownersownerid\ntag pets: Operations about pets\n  GET /pets #get-pets\n"
+			if !strings.HasPrefix(out, "overview Mini Store API (v") || !strings.Contains(out, want) {
+				t.Fatalf("expected the overview then each tag section with its operations, got:\n%s", out)
+			}
+			if !strings.Contains(out, "schemas Schemas: \n") || !strings.Contains(out, "  Owner [email id name preferences]\n") {
+				t.Fatalf("expected a Schemas section listing Owner, got:\n%s", out)
+			}
+
+			out, err = ToMarkdown(data, Options{Format: FormatJSON, Renderer: Elements(outlineRenderer{}), OmitSchemas: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(out, "Schemas") {
+				t.Fatalf("expected no Schemas section with OmitSchemas, got:\n%s", out)
+			}
+
+			// The Markdown output is driven by the same traversal, so it
+			// renders the same operations in the same order.
+			for _, opts := range []Options{{PrimaryTagOnly: true}, {Methods: []string{"GET"}, SortOperationsBy: SortByMethod}} {
+				opts.Format = FormatJSON
+				md, err := ToMarkdown(data, opts)
+				if err != nil {
+					t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+				}
+				opts.Renderer = Elements(outlineRenderer{})
+				out, err := ToMarkdown(data, opts)
+				if err != nil {
+					t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+				}
+				var headings, visited []string
+				for _, line := range strings.Split(md, "\n") {
+					if h, ok := strings.CutPrefix(line, "#### "); ok {
+						headings = append(headings, h)
+					}
+				}
+				for _, line := range strings.Split(out, "\n") {
+					if op, ok := strings.CutPrefix(line, "  "); ok && strings.Contains(op, " #") {
+						visited = append(visited, op[:strings.LastIndex(op, " #")])
+					}
+				}
+				if strings.Join(headings, ",") != strings.Join(visited, ",") {
+					t.Fatalf("%+v: Markdown renders %v, Elements visits %v", opts, headings, visited)
+				}
+			}
+		})
+	}
+}
+
+func TestExamples_DeterministicOutput(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.multi-examples.json", "testdata/v3.multi-examples.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			first, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			// Map iteration order varies between runs, so a handful of
+			// repeats catches any loop that is not sorted.
+			for i := 0; i < 20; i++ {
+				md, err := ToMarkdown(data, Options{Format: FormatJSON})
+				if err != nil {
+					t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+				}
+				if md != first {
+					t.Fatalf("output differs between runs:\n%s", UnifiedDiff("first", "repeat", first, md))
+				}
+			}
+		})
+	}
+}
+
+func TestPrimaryServerSelection(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.servers.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.servers.json: %v", err)
+	}
+	cases := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"default", Options{}, "- https://api.example.com/v1 — Production (primary)\n- https://staging-api.example.com/v1 — Staging\n- http://localhost:8080/v1 — Local development\n"},
+		{"index", Options{PrimaryServer: 2}, "- https://api.example.com/v1 — Production\n- https://staging-api.example.com/v1 — Staging\n- http://localhost:8080/v1 — Local development (primary)\n"},
+		{"match", Options{PrimaryServer: 2, PrimaryServerMatch: "STAGING"}, "- https://api.example.com/v1 — Production\n- https://staging-api.example.com/v1 — Staging (primary)\n- http://localhost:8080/v1 — Local development\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			md, err := ToMarkdown(data, tc.opts)
+			if err != nil {
+				t.Fatalf("ToMarkdown(v3.servers.json) returned error: %v", err)
+			}
+			if !strings.Contains(md, "## Servers\n"+tc.want) {
+				t.Fatalf("expected servers list:\n%s\ngot:\n%s", tc.want, md)
+			}
+		})
+	}
+
+	md, err := ToMarkdown(data, Options{PrimaryServer: 2, PrimaryServerMatch: "qa"})
+	if err != nil {
+		t.Fatalf("ToMarkdown with an unmatched PrimaryServerMatch returned error: %v", err)
+	}
+	if !strings.Contains(md, "## Servers\n"+cases[0].want) {
+		t.Fatalf("expected the first server to be primary when none matches, got:\n%s", md)
+	}
+	if _, err := ToMarkdown(data, Options{PrimaryServer: 3}); err == nil {
+		t.Fatalf("expected error for out-of-range server index, got nil")
+	}
+}
+
+func TestDeprecatedEnumValues(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.deprecated-enum.json", "testdata/v3.deprecated-enum.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "- `carrier` (string)\n  - `ups`\n  - `fedex`\n  - `pony` (deprecated)\n") {
+				t.Fatalf("expected carrier enum sub-list with pony deprecated, got:\n%s", md)
+			}
+			if !strings.Contains(md, "  - `express` — 1-2 business days.\n  - `overnight` (deprecated) — Next business day.\n") {
+				t.Fatalf("expected speed enum to combine descriptions and deprecation, got:\n%s", md)
+			}
+			if !strings.Contains(md, "- `size` (integer) [enum: 1, 2, 3]\n") {
+				t.Fatalf("expected size enum to stay inline without the configured key, got:\n%s", md)
+			}
+
+			md, err = ToMarkdown(data, Options{Format: FormatJSON, DeprecatedEnumKey: "x-sunset"})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "- `size` (integer)\n  - `1`\n  - `2`\n  - `3` (deprecated)\n") {
+				t.Fatalf("expected size enum to honor the configured key, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestOmitSchemas(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.json", "testdata/v3.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, OmitSchemas: true, TagModelIndex: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(md, "## Schemas") {
+				t.Fatalf("expected no Schemas section, got:\n%s", md)
+			}
+			if !strings.Contains(md, "**Models:**") {
+				t.Fatalf("expected a model index, got:\n%s", md)
+			}
+			// Nothing may point into the omitted Schemas section.
+			for _, anchor := range []string{"pet", "newpet", "owner", "petlist", "error"} {
+				if strings.Contains(md, "](#"+anchor+")") {
+					t.Fatalf("expected no link to the %q schema anchor, got:\n%s", anchor, md)
+				}
+			}
+			// Response links still point at operations.
+			if strings.Contains(fixture, "v3") && !strings.Contains(md, "  - Link `getOwner` → [GET /owners/{ownerId}](#get-ownersownerid) (") {
+				t.Fatalf("expected response link targets linked to their operations, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestInlineSchemaTitles(t *testing.T) {
+	cases := []struct {
+		fixture string
+		billing string
+	}{
+		{"testdata/v2.schema-titles.json", "- `billing` (Card)\n"},
+		{"testdata/v3.schema-titles.json", "- `billing` ($ref:Card)\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(tc.fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+			}
+			want := "- `address` (object) (title: Address)\n" + tc.billing + "- `name` (string) (title: Full name)\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected inline schema titles appended to the type, got:\n%s", md)
+			}
+
+			md, err = ToMarkdown(data, Options{Format: FormatJSON, PreferSchemaTitles: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+			}
+			want = "- `address` (Address)\n" + tc.billing + "- `name` (Full name)\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected inline schema titles as type labels, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestOnPhase(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.json: %v", err)
+	}
+	var phases []string
+	opts := Options{Format: FormatJSON, OnPhase: func(phase string, d time.Duration) {
+		if d < 0 {
+			t.Fatalf("negative duration for phase %s: %v", phase, d)
+		}
+		phases = append(phases, phase)
+	}}
+	if _, err := ToMarkdown(data, opts); err != nil {
+		t.Fatalf("ToMarkdown(v3.json) returned error: %v", err)
+	}
+	want := []string{PhaseNormalize, PhaseParse, PhaseValidate, PhaseRender}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Fatalf("phases = %v, want %v", phases, want)
+	}
+
+	phases = nil
+	if _, err := ToMarkdown([]byte(minimalSwagger2JSON), opts); err != nil {
+		t.Fatalf("ToMarkdown(minimalSwagger2JSON) returned error: %v", err)
+	}
+	want = []string{PhaseNormalize, PhaseParse, PhaseRender}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Fatalf("phases = %v, want %v", phases, want)
+	}
+}
+
+func TestOpenAPI3_OneOfRequestBody(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.oneof-body.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.oneof-body.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v3.oneof-body.json) returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- application/json — schema: oneOf([CreateUser](#createuser), [CreateBot](#createbot))\n",
+		"- application/merge-patch+json — schema: $ref:AccountPatch (oneOf([CreateUser](#createuser), [CreateBot](#createbot)))\n",
+		"- text/plain — schema: anyOf(string, [CreateBot](#createbot))\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected request body line %q, got:\n%s", want, md)
+		}
+	}
+
+	md, err = ToMarkdown(data, Options{Format: FormatJSON, OmitSchemas: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v3.oneof-body.json) returned error: %v", err)
+	}
+	if !strings.Contains(md, "- application/json — schema: oneOf(CreateUser, CreateBot)\n") {
+		t.Fatalf("expected plain alternative names without a Schemas section, got:\n%s", md)
+	}
+}
+
+func TestMethodsAllowlist(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.json", "testdata/v3.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, Methods: []string{"get", "HEAD"}})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "#### GET /pets\n") {
+				t.Fatalf("expected GET operations to be rendered, got:\n%s", md)
+			}
+			if strings.Contains(md, "#### POST ") || strings.Contains(md, "- POST ") {
+				t.Fatalf("expected POST operations to be filtered from every section, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestNumericBounds_ExclusiveForms(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.exclusive-bounds.json", "testdata/v3.exclusive-bounds.json", "testdata/v3.exclusive-bounds-31.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "- `celsius` (number) [exclusive min: -273.15, max: 1000]\n" +
+				"- `count` (integer) [min: 1, max: 10]\n" +
+				"- `level` (integer) [min: 5]\n" +
+				"- `ratio` (number) [min: 0, exclusive max: 1]\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected bounds:\n%s\ngot:\n%s", want, md)
+			}
+			// Example payloads are data, not schemas, and keep their keys.
+			if strings.HasSuffix(fixture, "-31.json") && !strings.Contains(md, `"exclusiveMinimum": 3`) {
+				t.Fatalf("expected the schema example to be left alone, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestNormalizeExclusiveBounds_LargeIntegers(t *testing.T) {
+	// Both values round to 9007199254740992 as float64, which would make the
+	// exclusive bound look at least as tight and replace the minimum.
+	in := `{"openapi":"3.1.0","components":{"schemas":{"Id":{"type":"integer","minimum":9007199254740993,"exclusiveMinimum":9007199254740992,"default":9007199254740995}}}}`
+	out := string(normalizeExclusiveBounds([]byte(in)))
+	for _, want := range []string{`"minimum":9007199254740993`, `"default":9007199254740995`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s to survive normalization, got %s", want, out)
+		}
+	}
+	if strings.Contains(out, "exclusiveMinimum") {
+		t.Fatalf("expected the looser exclusive bound to be dropped, got %s", out)
+	}
+}
+
+func TestConvertAll(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.json: %v", err)
+	}
+	opts := Options{Format: FormatJSON}
+	want, err := ToMarkdown(data, opts)
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	got, err := ConvertAll(data, opts, []OutputFormat{OutputMarkdown})
+	if err != nil {
+		t.Fatalf("ConvertAll returned error: %v", err)
+	}
+	if len(got) != 1 || got[OutputMarkdown] != want {
+		t.Fatalf("ConvertAll markdown output differs from ToMarkdown")
+	}
+
+	if _, err := ConvertAll(data, opts, []OutputFormat{OutputMarkdown, "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown output format, got nil")
+	}
+}
+
+func TestOperationMarkers(t *testing.T) {
+	cases := []struct {
+		file, want string
+	}{
+		// v2.json has no operationIds, so markers fall back to method and path.
+		{"testdata/v2.json", "<!-- operation: GET /pets -->\n#### GET /pets\n"},
+		{"testdata/v3.json", "<!-- operation: getOwner (GET /owners/{ownerId}) -->\n#### GET /owners/{ownerId}\n"},
+	}
+	for _, tc := range cases {
+		file := tc.file
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			md, err := ToMarkdown(data, Options{})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if strings.Contains(md, "<!-- operation:") {
+				t.Fatalf("expected no operation markers by default")
+			}
+
+			md, err = ToMarkdown(data, Options{OperationMarkers: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if got, want := strings.Count(md, "<!-- operation: "), strings.Count(md, "\n#### "); got == 0 || got != want {
+				t.Fatalf("expected one marker per operation heading, got %d markers for %d headings", got, want)
+			}
+			if !strings.Contains(md, tc.want) {
+				t.Fatalf("expected %q, got: %s", tc.want, md)
+			}
+		})
+	}
+}
+
+func TestSplitReadWrite(t *testing.T) {
+	cases := []struct {
+		file, want string
+	}{
+		{"testdata/v3.read-write.json", "\n**Required by direction**\n- Request: `name`, `password`\n- Response: `id`, `name`\n"},
+		{"testdata/v2.read-write.json", "\n**Required by direction**\n- Request: `name`\n- Response: `id`, `name`\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := os.ReadFile(tc.file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tc.file, err)
+			}
+			md, err := ToMarkdown(data, Options{})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if strings.Contains(md, "Required by direction") {
+				t.Fatalf("expected no per-direction lists by default, got: %s", md)
+			}
+
+			md, err = ToMarkdown(data, Options{SplitReadWrite: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if !strings.Contains(md, tc.want) {
+				t.Fatalf("expected %q, got: %s", tc.want, md)
+			}
+			// Tag has no read-only or write-only required properties.
+			if strings.Count(md, "Required by direction") != 1 {
+				t.Fatalf("expected only User to get per-direction lists, got: %s", md)
+			}
+		})
+	}
+}
+
+func TestInputEncoding(t *testing.T) {
+	utf8Data, err := os.ReadFile("testdata/v2.json")
+	if err != nil {
+		t.Fatalf("failed to read v2.json: %v", err)
+	}
+	want, err := ToMarkdown(utf8Data, Options{})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+
+	// BOM-marked UTF-16 is detected without configuration.
+	utf16Data, err := os.ReadFile("testdata/v2.utf16.json")
+	if err != nil {
+		t.Fatalf("failed to read v2.utf16.json: %v", err)
+	}
+	if got, err := ToMarkdown(utf16Data, Options{}); err != nil || got != want {
+		t.Fatalf("UTF-16 input rendered differently from UTF-8 (err %v)", err)
+	}
+	if got, err := ToMarkdown(utf16Data[2:], Options{InputEncoding: "UTF-16LE"}); err != nil || got != want {
+		t.Fatalf("UTF-16LE input without BOM rendered differently from UTF-8 (err %v)", err)
+	}
+
+	latin1 := []byte("{\"swagger\": \"2.0\", \"info\": {\"title\": \"Caf\xe9 API\", \"version\": \"1\"}, \"paths\": {}}")
+	md, err := ToMarkdown(latin1, Options{InputEncoding: EncodingLatin1})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error for Latin-1 input: %v", err)
+	}
+	if !strings.HasPrefix(md, "# Café API\n") {
+		t.Fatalf("expected Latin-1 title decoded to UTF-8, got: %s", md[:min(80, len(md))])
+	}
+
+	if _, err := ToMarkdown(utf8Data, Options{InputEncoding: "ebcdic"}); err == nil || !strings.Contains(err.Error(), "unsupported input encoding") {
+		t.Fatalf("expected unsupported encoding error, got %v", err)
+	}
+}
+
+func TestDeprecatedResponseMediaType(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.deprecated-media.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.deprecated-media.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"  - application/json — schema: array<string>\n",
+		"  - application/xml (deprecated) — schema: string\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+
+	// A custom key replaces the default rather than adding to it.
+	md, err = ToMarkdown(data, Options{Format: FormatJSON, DeprecatedMediaTypeKey: "x-retired"})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(md, "(deprecated)") {
+		t.Fatalf("expected no deprecated media types with an unused key, got: %s", md)
+	}
+}
+
+func TestExamplesSection(t *testing.T) {
+	for _, file := range []string{"testdata/v2.examples.json", "testdata/v3.examples.json"} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			md, err := ToMarkdown(data, Options{})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if strings.Contains(md, "## Examples") || strings.Contains(md, "has inline examples") {
+				t.Fatalf("expected no Examples section by default, got: %s", md)
+			}
+			if !strings.Contains(md, "Response example (") {
+				t.Fatalf("expected inline response examples under operations, got: %s", md)
+			}
+
+			md, err = ToMarkdown(data, Options{ExamplesSection: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if !strings.Contains(md, "\n## Examples\n") || !strings.Contains(md, " — has inline examples\n") {
+				t.Fatalf("expected Examples index with ExamplesSection, got: %s", md)
+			}
+		})
+	}
+}
+
+func TestServerVariableExample(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.server-variables.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.server-variables.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- https://{region}.api.example.com/{basePath} {vars} — Regional endpoint (primary)\n  - Example: `https://us-east.api.example.com/v2`\n",
+		"  - Example: `https://{tenant}.blue.example.com`\n  - Note: `{tenant}` has no default or enum value and is left as a placeholder\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+}
+
+func TestReusableComponentSections(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.reusable-components.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.reusable-components.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(md, "## Reusable") || strings.Contains(md, "(shared: [") {
+		t.Fatalf("expected no reusable sections or links by default, got: %s", md)
+	}
+
+	md, err = ToMarkdown(data, Options{Format: FormatJSON, ReusableParameters: true, ReusableRequestBodies: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- query `limit` (integer) (shared: [limit](#limit)) — Page size. [default: 20] [min: 1, max: 100]\n",
+		"- query `q` (string)\n",
+		"\n**Request Body** (shared: [PetBody](#petbody))\n",
+		"\n## Reusable Parameters\n\n### limit\n- query `limit` (integer) — Page size. [default: 20] [min: 1, max: 100]\n\n### traceId\n- header `X-Trace-Id` (string) (required)\n",
+		"\n## Reusable Request Bodies\n\n### PetBody\nA pet to store.\n\nRequired.\n\n- application/json — schema: $ref:Pet\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+}
+
+func TestStringConstraints(t *testing.T) {
+	data, err := os.ReadFile("testdata/v2.string-constraints.json")
+	if err != nil {
+		t.Fatalf("failed to read v2.string-constraints.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- query `handle` (string) [constraints: minLength: 3, maxLength: 32, pattern: `^[a-z0-9_]+$`]\n",
+		"- query `since` (string (date-time))\n",
+		"- `bio` (string) [constraints: maxLength: 280]\n",
+		"- `email` (string (email))\n",
+		"- `handle` (string) [constraints: minLength: 3, maxLength: 32, pattern: `^[a-z0-9_]+$`]\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+
+	// OpenAPI 3 renders the same constraints through the shared helper.
+	v3 := `{"openapi": "3.0.3", "info": {"title": "T", "version": "1"}, "paths": {"/u": {"get": {
+		"parameters": [{"name": "handle", "in": "query", "schema": {"type": "string", "minLength": 3, "pattern": "^[a-z]+$"}}],
+		"responses": {"200": {"description": "OK"}}}}},
+		"components": {"schemas": {"U": {"type": "object", "properties": {"bio": {"type": "string", "maxLength": 280}}}}}}`
+	md, err = ToMarkdown([]byte(v3), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- query `handle` (string) [constraints: minLength: 3, pattern: `^[a-z]+$`]\n",
+		"- `bio` (string) [constraints: maxLength: 280]\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+}
+
+func TestJSONLOutput(t *testing.T) {
+	for _, file := range []string{"testdata/v2.json", "testdata/v3.json"} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			md, err := ToMarkdown(data, Options{})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			out, err := ToMarkdown(data, Options{OutputFormat: OutputJSONL, WrapWidth: 20})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			if want := strings.Count(md, "\n#### "); len(lines) != want {
+				t.Fatalf("expected %d lines (one per operation heading), got %d:\n%s", want, len(lines), out)
+			}
+			for _, line := range lines {
+				if !utf8.ValidString(line) || !json.Valid([]byte(line)) {
+					t.Fatalf("invalid JSON line: %s", line)
+				}
+				if !strings.HasPrefix(line, `{"method":`) {
+					t.Fatalf("expected method as the first field, got: %s", line)
+				}
+				var rec map[string]any
+				if err := json.Unmarshal([]byte(line), &rec); err != nil {
+					t.Fatalf("unmarshal %s: %v", line, err)
+				}
+				for _, field := range []string{"method", "path", "operationId", "tags", "summary", "description", "anchor"} {
+					if _, ok := rec[field]; !ok {
+						t.Fatalf("missing field %q in %s", field, line)
+					}
+				}
+				heading := rec["method"].(string) + " " + rec["path"].(string)
+				if !strings.Contains(md, "\n#### "+heading+"\n") || rec["anchor"] != "#"+githubSlug(heading) {
+					t.Fatalf("anchor %v does not match heading %q", rec["anchor"], heading)
+				}
+			}
+		})
+	}
+}
+
+func TestOperationIntroGolden(t *testing.T) {
+	const want = "\n#### GET /pets/{id}\n" +
+		"**Get a pet**\n" +
+		"\n" +
+		"Returns the pet with the given ID.\n" +
+		"\n" +
+		"_Operation ID_: `getPet`\n" +
+		"\n" +
+		"**Parameters**\n" +
+		"- path `id` (string) (required)\n" +
+		"\n" +
+		"**Responses**\n" +
+		"- 200 — OK\n"
+	for _, file := range []string{"testdata/v2.operation-intro.json", "testdata/v3.operation-intro.json"} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			i := strings.Index(md, "\n#### ")
+			j := strings.Index(md, "\n## Schemas")
+			if j < 0 {
+				j = len(md)
+			}
+			if i < 0 || md[i:j] != want {
+				t.Fatalf("operation block mismatch\n%s", UnifiedDiff("want", "got", want, md[max(i, 0):j]))
+			}
+			if strings.Contains(md, "\n\n\n") {
+				t.Fatalf("expected no doubled blank lines, got: %s", md)
+			}
+		})
+	}
+}
+
+func TestOmitEmptySections(t *testing.T) {
+	specs := map[string]string{
+		"swagger2": minimalSwagger2JSON,
+		"openapi3": `{"openapi": "3.0.3", "info": {"title": "Minimal API", "version": "1.0.0"}, "paths": {}}`,
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			md, err := ToMarkdown([]byte(spec), Options{Format: FormatJSON, ExamplesSection: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if !strings.Contains(md, "- None defined\n") {
+				t.Fatalf("expected None defined placeholders by default, got: %s", md)
+			}
+
+			md, err = ToMarkdown([]byte(spec), Options{Format: FormatJSON, ExamplesSection: true, OmitEmptySections: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			if strings.Contains(md, "None defined") {
+				t.Fatalf("expected no None defined lines, got: %s", md)
+			}
+			for _, heading := range []string{"## Authentication", "## Servers", "## Tags", "## Examples"} {
+				if strings.Contains(md, heading) {
+					t.Fatalf("expected empty section %q to be omitted, got: %s", heading, md)
+				}
+			}
+			if !strings.HasPrefix(md, "# Minimal API\n") || !strings.Contains(md, "\n## Overview\n") {
+				t.Fatalf("expected title and overview to remain, got: %s", md)
+			}
+		})
+	}
+}
+
+func TestPatternProperties(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.pattern-properties.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.pattern-properties.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "- `name` (string)\n\n**Pattern-keyed properties**\n- `^[0-9]+$` → $ref:Label\n- `^x-.*$` → string\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected %q, got: %s", want, md)
+	}
+	// Schemas without the keyword are unchanged.
+	if strings.Count(md, "Pattern-keyed properties") != 1 {
+		t.Fatalf("expected a single pattern-keyed list, got: %s", md)
+	}
+}
+
+func TestLineEndingCRLF(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON, LineEnding: LineEndingCRLF})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(md, "\r\n") {
+		t.Fatalf("expected CRLF line endings, got: %q", md)
+	}
+	if n := strings.Count(md, "\n"); n != strings.Count(md, "\r\n") {
+		t.Fatalf("expected no lone \\n, got %d \\n for %d \\r\\n", n, strings.Count(md, "\r\n"))
+	}
+	lf, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.ReplaceAll(md, "\r\n", "\n") != lf {
+		t.Fatalf("expected CRLF output to differ from LF output only in line endings")
+	}
+
+	if _, err := ToMarkdown(data, Options{Format: FormatJSON, LineEnding: "cr"}); err == nil {
+		t.Fatalf("expected an error for an unknown line ending")
+	}
+}
+
+func TestDefaultResponseLast(t *testing.T) {
+	docs := map[string]string{
+		"v2": `{"swagger": "2.0", "info": {"title": "T", "version": "1"}, "paths": {"/x": {"get": {
+			"responses": {"default": {"description": "Error"}, "200": {"description": "OK"}, "Legacy": {"description": "Old"}}}}}}`,
+		"v3": `{"openapi": "3.0.3", "info": {"title": "T", "version": "1"}, "paths": {"/x": {"get": {
+			"responses": {"default": {"description": "Error"}, "200": {"description": "OK"}, "4XX": {"description": "Client"}}}}}}`,
+	}
+	for name, doc := range docs {
+		md, err := ToMarkdown([]byte(doc), Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("%s: ToMarkdown returned error: %v", name, err)
+		}
+		want := "- default — applies to all undocumented status codes — Error\n"
+		i := strings.Index(md, want)
+		if i < 0 {
+			t.Fatalf("%s: expected %q, got: %s", name, want, md)
+		}
+		if rest := md[i+len(want):]; strings.HasPrefix(rest, "- ") {
+			t.Fatalf("%s: expected default to be the last response, got: %s", name, md)
+		}
+	}
+}
+
+func TestSpecialCharactersAsInlineCode(t *testing.T) {
+	v3 := `{"openapi": "3.0.3", "info": {"title": "T", "version": "1"}, "paths": {"/f": {"get": {
+		"parameters": [{"name": "glob", "in": "query", "schema": {"type": "string", "default": "*_draft_*"}}],
+		"responses": {"200": {"description": "OK"}}}}},
+		"components": {"schemas": {"Filter": {"type": "object", "properties": {
+			"match": {"type": "string", "default": "^__init__.*$", "pattern": "^[a-z_]*$"},
+			"quote": {"type": "string", "pattern": "^` + "`" + `[^` + "`" + `]+` + "`" + `$"},
+			"plain": {"type": "string", "default": "asc"}}}}}}`
+	md, err := ToMarkdown([]byte(v3), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"- query `glob` (string) [default: `*_draft_*`]\n",
+		"- `match` (string) [default: `^__init__.*$`] [constraints: pattern: `^[a-z_]*$`]\n",
+		"- `quote` (string) [constraints: pattern: ``^`[^`]+`$``]\n",
+		"- `plain` (string) [default: asc]\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+
+	v2 := `{"swagger": "2.0", "info": {"title": "T", "version": "1"}, "paths": {"/f": {"get": {
+		"parameters": [{"name": "ts", "in": "query", "type": "string", "format": "x_unix_*", "default": "**now**"}],
+		"responses": {"200": {"description": "OK"}}}}}}`
+	md, err = ToMarkdown([]byte(v2), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if want := "- query `ts` (string (`x_unix_*`)) [default: `**now**`]\n"; !strings.Contains(md, want) {
+		t.Fatalf("expected %q, got: %s", want, md)
+	}
+}
+
+func TestIncludeOperationServers(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.operation-servers.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.operation-servers.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON, IncludeOperationServers: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"#### GET /files\n**List files**\n\n**Servers**\n- https://files.example.com — File storage\n",
+		"#### POST /files\n**Upload a file**\n\n**Servers**\n" +
+			"- https://upload.{region}.example.com {vars} — Regional upload host (primary)\n" +
+			"  - Example: `https://upload.eu.example.com`\n" +
+			"- https://upload.example.com — Global upload host\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+	// Operations without overrides use the document servers and get no list.
+	if !strings.Contains(md, "#### GET /status\n**Service status**\n\n**Responses**\n") {
+		t.Fatalf("expected no servers list for GET /status, got: %s", md)
+	}
+
+	// The primary server options apply to the overrides too.
+	md, err = ToMarkdown(data, Options{Format: FormatJSON, IncludeOperationServers: true, PrimaryServerMatch: "global"})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(md, "- https://upload.example.com — Global upload host (primary)\n") {
+		t.Fatalf("expected PrimaryServerMatch to mark the global upload host, got: %s", md)
+	}
+
+	plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(plain, "files.example.com") {
+		t.Fatalf("expected operation servers only with IncludeOperationServers, got: %s", plain)
+	}
+}
+
+func TestIncludeBranding(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    string
+	}{
+		{"testdata/v3.branding.json", "[![Example Inc.](https://example.com/logo.png)](https://example.com)\n\n# Branded API\n"},
+		{"testdata/v2.branding.json", "![logo](https://example.com/logo.png)\n\n# Branded API\n"},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, IncludeBranding: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if !strings.HasPrefix(md, tc.want) {
+			t.Fatalf("%s: expected output to start with %q, got:\n%s", tc.fixture, tc.want, md)
+		}
+
+		plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if !strings.HasPrefix(plain, "# Branded API\n") {
+			t.Fatalf("%s: expected no logo unless IncludeBranding is set, got:\n%s", tc.fixture, plain)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    Summary
+	}{
+		{"testdata/v2.json", Summary{Paths: 4, Operations: 6, Schemas: 4, Tags: 2, SecuritySchemes: 2}},
+		{"testdata/v3.json", Summary{Paths: 3, Operations: 5, Schemas: 5, Tags: 2, SecuritySchemes: 2}},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		got, err := Summarize(data, Options{})
+		if err != nil {
+			t.Fatalf("Summarize(%s) returned error: %v", tc.fixture, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Summarize(%s) = %+v, want %+v", tc.fixture, got, tc.want)
+		}
+	}
+
+	if _, err := Summarize([]byte("{not json"), Options{Format: FormatJSON}); err == nil {
+		t.Fatalf("expected error for unparsable input, got nil")
+	}
+}
+
+func TestCookieParameter(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.cookie-parameter.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.cookie-parameter.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "**Parameters**\n" +
+		"- cookie `session` (string) (required) — Session identifier set at login\n" +
+		"- header `session` (string) — Session identifier for clients without cookies\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected %q, got: %s", want, md)
+	}
+}
+
+func TestSwagger2PathLevelParameters(t *testing.T) {
+	data, err := os.ReadFile("testdata/v2.path-parameters.json")
+	if err != nil {
+		t.Fatalf("failed to read v2.path-parameters.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"#### GET /accounts/{accountId}\n**Get an account**\n\n**Parameters**\n" +
+			"- path `accountId` (string) (required) — Account identifier\n" +
+			"- header `X-Request-ID` (string) — Correlation ID\n",
+		// The operation's own X-Request-ID replaces the shared one.
+		"#### DELETE /accounts/{accountId}\n**Close an account**\n\n**Parameters**\n" +
+			"- path `accountId` (string) (required) — Account identifier\n" +
+			"- header `X-Request-ID` (string) (required) — Correlation ID, required for audit\n\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+}
+
+func TestIndexIncludeFirstExample(t *testing.T) {
+	long := strings.Repeat("x", 200)
+	v3 := `{"openapi": "3.0.3", "info": {"title": "T", "version": "1"}, "paths": {
+		"/notes": {"post": {"requestBody": {"content": {"application/json": {
+			"schema": {"type": "object"},
+			"example": {"title": "Groceries", "body": "` + long + `"}}}},
+			"responses": {"200": {"description": "OK"}}},
+		"get": {"responses": {"200": {"description": "OK"}}}}}}`
+	out, err := ToMarkdown([]byte(v3), Options{Format: FormatJSON, OutputFormat: OutputJSONL, IndexIncludeFirstExample: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got: %s", out)
+	}
+	var get, post struct {
+		Method  string  `json:"method"`
+		Example *string `json:"example"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &get); err != nil || get.Example != nil {
+		t.Fatalf("expected no example on GET /notes, got %s (%v)", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &post); err != nil || post.Example == nil {
+		t.Fatalf("expected an example on POST /notes, got %s (%v)", lines[1], err)
+	}
+	want := `{"body":"` + strings.Repeat("x", 110) + "…"
+	if *post.Example != want {
+		t.Fatalf("expected truncated example %q, got %q", want, *post.Example)
+	}
+
+	plain, err := ToMarkdown([]byte(v3), Options{Format: FormatJSON, OutputFormat: OutputJSONL})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(plain, `"example"`) {
+		t.Fatalf("expected no examples unless IndexIncludeFirstExample is set, got: %s", plain)
+	}
+}
+
+func TestDiscriminatedRequestBody(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.discriminated-body.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.discriminated-body.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"  - Discriminator: `type`\n" +
+			"  - `click` → [ClickEvent](#clickevent)\n" +
+			"  - `page_view` → [PageViewEvent](#pageviewevent)\n" +
+			"  - `PurchaseEvent` → [PurchaseEvent](#purchaseevent)\n",
+		// The variant schema's example, then a named example selecting a variant.
+		"Request example (click, application/json)\n```json\n",
+		"Request example (page_view: pageView, application/json)\n_A page view_\n",
+		// Named examples that select no variant are listed as before.
+		"Request example (other, application/json)\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got: %s", want, md)
+		}
+	}
+	if strings.Contains(md, "Request example (pageView, application/json)") {
+		t.Fatalf("expected the variant's named example not to be repeated, got: %s", md)
+	}
+}
+
+func TestRequiredHeadersSummary(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    []string
+		absent  string
+	}{
+		{"testdata/v3.required-headers.json", []string{
+			"#### GET /orders\n**List orders**\n\n**Required headers:** `X-API-Key`, `X-Tenant`, `X-Request-ID`\n\n**Parameters**\n",
+			// Alternative apiKey schemes are listed together.
+			"#### POST /orders\n**Create an order**\n\n**Security**\n- apiKey OR partnerKey\n\n**Required headers:** `X-API-Key` or `X-Partner-Key`, `X-Tenant`\n",
+		}, "#### GET /health\n**Health check**\n\n**Security**\n- None (public)\n\n**Required headers"},
+		{"testdata/v2.required-headers.json", []string{
+			"#### GET /orders\n**List orders**\n\n**Required headers:** `X-API-Key`, `X-Request-ID`\n",
+		}, "#### POST /orders\n**Create an order**\n\n**Security**\n- apiKey OR queryKey\n\n**Required headers"},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, RequiredHeadersSummary: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", tc.fixture, want, md)
+			}
+		}
+		// A public operation, or one whose alternatives don't all need a
+		// header, has no security headers to list.
+		if strings.Contains(md, tc.absent) {
+			t.Fatalf("%s: expected no required headers line, got:\n%s", tc.fixture, md)
+		}
+
+		plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if strings.Contains(plain, "Required headers") {
+			t.Fatalf("%s: expected no summary unless RequiredHeadersSummary is set", tc.fixture)
+		}
+	}
+}
+
+func TestSortOperationsBy(t *testing.T) {
+	docs := map[string]string{
+		"v2": `{"swagger": "2.0", "info": {"title": "T", "version": "1"}, "paths": {
+			"/a": {"post": {"operationId": "zeta", "responses": {"200": {"description": "OK"}}},
+			       "get": {"responses": {"200": {"description": "OK"}}}},
+			"/b": {"get": {"operationId": "alpha", "responses": {"200": {"description": "OK"}}},
+			       "delete": {"operationId": "mid", "responses": {"200": {"description": "OK"}}}}}}`,
+		"v3": `{"openapi": "3.0.3", "info": {"title": "T", "version": "1"}, "paths": {
+			"/a": {"post": {"operationId": "zeta", "responses": {"200": {"description": "OK"}}},
+			       "get": {"responses": {"200": {"description": "OK"}}}},
+			"/b": {"get": {"operationId": "alpha", "responses": {"200": {"description": "OK"}}},
+			       "delete": {"operationId": "mid", "responses": {"200": {"description": "OK"}}}}}}`,
+	}
+	cases := []struct {
+		by   OperationSort
+		want []string
+	}{
+		{"", []string{"GET /a", "POST /a", "GET /b", "DELETE /b"}},
+		{SortByPath, []string{"GET /a", "POST /a", "GET /b", "DELETE /b"}},
+		{SortByMethod, []string{"GET /a", "GET /b", "POST /a", "DELETE /b"}},
+		{SortByOperationID, []string{"GET /b", "DELETE /b", "POST /a", "GET /a"}},
+	}
+	for name, doc := range docs {
+		for _, tc := range cases {
+			md, err := ToMarkdown([]byte(doc), Options{Format: FormatJSON, SortOperationsBy: tc.by})
+			if err != nil {
+				t.Fatalf("%s/%s: ToMarkdown returned error: %v", name, tc.by, err)
+			}
+			var got []string
+			for _, line := range strings.Split(md, "\n") {
+				if strings.HasPrefix(line, "#### ") {
+					got = append(got, strings.TrimPrefix(line, "#### "))
+				}
+			}
+			if strings.Join(got, ", ") != strings.Join(tc.want, ", ") {
+				t.Fatalf("%s/%s: operations in order %v, want %v", name, tc.by, got, tc.want)
+			}
+		}
+		if _, err := ToMarkdown([]byte(doc), Options{Format: FormatJSON, SortOperationsBy: "tag"}); err == nil {
+			t.Fatalf("%s: expected an error for an unknown operation sort", name)
+		}
+	}
+}
+
+func TestNonJSONResponseBodies(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.binary-download.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.binary-download.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "- 200 — The report\n" +
+		"  - application/json — schema: $ref:Report\n" +
+		"  - application/octet-stream — binary body (format: binary)\n" +
+		"  - image/png — binary body\n" +
+		"  - text/csv — text body\n" +
+		"  - text/plain — plain text body\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected %q, got: %s", want, md)
+	}
+}
+
+func TestAnchorPrefix(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.reusable-components.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.reusable-components.json: %v", err)
+	}
+	opts := Options{Format: FormatJSON, AnchorPrefix: "pets-", ReusableParameters: true, ReusableRequestBodies: true}
+	md, err := ToMarkdown(data, opts)
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		// Headings carry explicit prefixed anchors...
+		"\n<a id=\"pets-untagged\"></a>\n### Untagged\n",
+		"<a id=\"pets-get-pets\"></a>\n#### GET /pets\n",
+		"\n<a id=\"pets-pet\"></a>\n### Pet\n",
+		"\n<a id=\"pets-limit\"></a>\n### limit\n",
+		"\n<a id=\"pets-petbody\"></a>\n### PetBody\n",
+		// ...and links point at them.
+		"(shared: [limit](#pets-limit))",
+		"**Request Body** (shared: [PetBody](#pets-petbody))",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got:\n%s", want, md)
+		}
+	}
+
+	opts.OutputFormat = OutputJSONL
+	jsonl, err := ToMarkdown(data, opts)
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(jsonl, `"anchor":"#pets-get-pets"`) {
+		t.Fatalf("expected prefixed JSONL anchor, got: %s", jsonl)
+	}
+
+	plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(plain, "<a id=") {
+		t.Fatalf("expected no explicit anchors without AnchorPrefix, got:\n%s", plain)
+	}
+}
+
+func TestInlineRequiredRecap(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.required-recap.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON, InlineRequiredRecap: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		// Resolved through $ref.
+		"**Request Body**\n- application/json — schema: $ref:NewUser (required: id, name)\n",
+		// allOf members contribute their required lists.
+		"  - application/json — schema: $ref:User (required: id, name, createdAt)\n",
+		// No required properties, no recap.
+		"- 400 — Invalid user\n  - application/json — schema: object\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got:\n%s", want, md)
+		}
+	}
+
+	md, err = ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(md, "(required:") {
+		t.Fatalf("expected no recap by default, got:\n%s", md)
+	}
+}
+
+func TestAdditionalProperties(t *testing.T) {
+	cases := []struct {
+		fixture string
+		mapType string
+	}{
+		{"testdata/v3.additional-properties.json", "$ref:Address"},
+		{"testdata/v2.additional-properties.json", "Address"},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		for _, want := range []string{
+			"### Address\n**Properties**\n- `street` (string)\n\n(closed — no additional properties)\n",
+			"### Metadata\n**Properties**\n- `source` (string)\n\n(open — additional properties of any type)\n",
+			"### AddressBook\n(map — additional properties: " + tc.mapType + ")\n",
+		} {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", tc.fixture, want, md)
+			}
+		}
+		// Without the keyword nothing is said about extra properties.
+		if !strings.HasSuffix(md, "### Plain\n**Properties**\n- `id` (string)\n") {
+			t.Fatalf("%s: expected no note for Plain, got:\n%s", tc.fixture, md)
+		}
+	}
+}
+
+func TestGroupParametersByLocation(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    []string
+	}{
+		{"testdata/v3.cookie-parameter.json", []string{
+			"**Query Parameters**\n- query `fields` (string)\n\n**Header Parameters**\n- header `session` (string) — Session identifier for clients without cookies\n\n**Cookie Parameters**\n- cookie `session` (string) (required) — Session identifier set at login\n",
+		}},
+		{"testdata/v2.json", []string{
+			"**Path Parameters**\n- path `id` (string) (required)\n\n**Query Parameters**\n- query `hard` (boolean) [default: false]\n",
+			"**Form Parameters**\n- formData `id` (string) (required) — Pet ID\n- formData `file` (file) (required) — Image file\n",
+		}},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, GroupParametersByLocation: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", tc.fixture, want, md)
+			}
+		}
+		if strings.Contains(md, "**Parameters**") {
+			t.Fatalf("%s: expected no flat parameter list, got:\n%s", tc.fixture, md)
+		}
+
+		// The flat list stays the default.
+		md, err = ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if !strings.Contains(md, "**Parameters**") || strings.Contains(md, "**Query Parameters**") {
+			t.Fatalf("%s: expected the flat parameter list by default, got:\n%s", tc.fixture, md)
+		}
+	}
+}
+
+func TestEnumValuesQuoteStrings(t *testing.T) {
+	for _, fixture := range []string{"testdata/v3.mixed-enum.json", "testdata/v2.mixed-enum.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		// Strings are quoted; numbers and booleans stay bare.
+		want := `— Accepted setting values. [enum: "1", 1, "true", true, 2.5, "on"]` + "\n"
+		if !strings.Contains(md, want) {
+			t.Fatalf("%s: expected %q, got:\n%s", fixture, want, md)
+		}
+	}
+
+	data, err := os.ReadFile("testdata/v2.mixed-enum.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if want := "- query `state` (string) [enum: \"active\", \"pending\"]\n"; !strings.Contains(md, want) {
+		t.Fatalf("expected %q, got:\n%s", want, md)
+	}
+}
+
+func TestSecurityScopeDescriptions(t *testing.T) {
+	for _, fixture := range []string{"testdata/v3.oauth-scopes.json", "testdata/v2.oauth-scopes.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		for _, want := range []string{
+			// A scope without a description stays bare.
+			"**List pets**\n\n**Security**\n- petstore_auth [read (Read access to pets), admin]\n",
+			"**Add a pet**\n\n**Security**\n- petstore_auth [write (Modify pets)]\n",
+		} {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", fixture, want, md)
+			}
+		}
+	}
+}
+
+func TestEmitModelDiagram(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    []string
+	}{
+		{"testdata/v3.json", []string{"  class Pet {\n", "    +Owner owner\n", "  Pet --> Owner : owner\n"}},
+		{"testdata/v2.json", []string{"  class PetList {\n    +Pet[] items\n", "  PetList --> Pet : items\n"}},
+	}
+	for _, tc := range cases {
+		data, err := os.ReadFile(tc.fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, EmitModelDiagram: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if !strings.Contains(md, "## Schemas\n\n```mermaid\nclassDiagram\n") {
+			t.Fatalf("%s: expected a classDiagram fence under Schemas, got:\n%s", tc.fixture, md)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", tc.fixture, want, md)
+			}
+		}
+
+		// Capped diagrams say so and drop associations to left-out schemas.
+		md, err = ToMarkdown(data, Options{Format: FormatJSON, EmitModelDiagram: true, ModelDiagramMaxNodes: 1})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", tc.fixture, err)
+		}
+		if !strings.Contains(md, "Model diagram limited to the first 1 of ") || strings.Contains(md, " --> ") {
+			t.Fatalf("%s: expected a capped diagram, got:\n%s", tc.fixture, md)
+		}
+	}
+
+	data, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(md, "classDiagram") {
+		t.Fatalf("expected no diagram by default, got:\n%s", md)
+	}
+}
+
+func TestExampleOnlyMediaType(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.example-only.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"**Request Body**\n- application/json (schema not specified)\nRequest example (application/json)\n```json\n",
+		"  - application/json (schema not specified)\nResponse example (created, 201, application/json)\n",
+		// Without an example there is nothing to point to instead.
+		"- application/x-www-form-urlencoded — schema: -\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got:\n%s", want, md)
+		}
+	}
+}
+
+func TestDebugPanics(t *testing.T) {
+	for _, fixture := range []string{"testdata/v3.json", "testdata/v2.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		for _, phase := range []string{PhaseParse, PhaseRender} {
+			panicAt := func(p string, _ time.Duration) {
+				if p == phase {
+					panic("boom")
+				}
+			}
+			_, err := ToMarkdown(data, Options{Format: FormatJSON, OnPhase: panicAt})
+			if err == nil || !strings.Contains(err.Error(), "conversion panic during "+phase+": boom") {
+				t.Fatalf("%s: expected a panic error naming the %s phase, got %v", fixture, phase, err)
+			}
+			if strings.Contains(err.Error(), "goroutine") {
+				t.Fatalf("%s: expected no stack without DebugPanics, got %v", fixture, err)
+			}
+
+			_, err = ToMarkdown(data, Options{Format: FormatJSON, OnPhase: panicAt, DebugPanics: true})
+			if err == nil || !strings.Contains(err.Error(), "boom at ") || !strings.Contains(err.Error(), "markdown_test.go:") || !strings.Contains(err.Error(), "goroutine") {
+				t.Fatalf("%s: expected the panic site and stack with DebugPanics, got %v", fixture, err)
+			}
+		}
+	}
+}
+
+func TestPrimaryTagOnly(t *testing.T) {
+	for _, fixture := range []string{"testdata/v3.multi-tag.json", "testdata/v2.multi-tag.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, PrimaryTagOnly: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		if n := strings.Count(md, "#### DELETE /pets/{id}\n"); n != 1 {
+			t.Fatalf("%s: expected one full rendering of DELETE /pets/{id}, got %d:\n%s", fixture, n, md)
+		}
+		for _, want := range []string{
+			"### admin\n\n**Also in this tag**\n- [DELETE /pets/{id}](#delete-petsid) — under pets\n\n#### GET /audit\n",
+			"### pets\n\n#### DELETE /pets/{id}\n",
+		} {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", fixture, want, md)
+			}
+		}
+
+		// By default the operation is rendered under every tag.
+		md, err = ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		if n := strings.Count(md, "#### DELETE /pets/{id}\n"); n != 2 {
+			t.Fatalf("%s: expected DELETE /pets/{id} under both tags, got %d:\n%s", fixture, n, md)
+		}
+	}
+}
+
+func TestArrayExampleFromItems(t *testing.T) {
+	for _, fixture := range []string{"testdata/v3.array-example.json", "testdata/v2.array-example.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		for _, want := range []string{
+			// The item example is wrapped in a one-element array.
+			"### PetList\nExample\n```json\n[\n  {\n    \"name\": \"Rex\"\n  }\n]\n```\n",
+			// An array-level example wins over the item example.
+			"### Tags\nExample\n```json\n[\n  \"friendly\",\n  \"small\"\n]\n```\n",
+		} {
+			if !strings.Contains(md, want) {
+				t.Fatalf("%s: expected %q, got:\n%s", fixture, want, md)
+			}
+		}
+	}
+
+	data, err := os.ReadFile("testdata/v2.array-example.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if want := "Request example\n```json\n[\n  {\n    \"name\": \"Rex\"\n  }\n]\n```\n"; !strings.Contains(md, want) {
+		t.Fatalf("expected the body example wrapped in an array, got:\n%s", md)
+	}
+}
+
+func TestAppendRawSpec(t *testing.T) {
+	for _, fixture := range []string{"testdata/v3.cookie-parameter.json", "testdata/v2.multi-tag.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		md, err := ToMarkdown(data, Options{Format: FormatJSON, AppendRawSpec: true})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		const head = "\n## Appendix: Source Spec\n\n<details>\n<summary>Source spec (JSON)</summary>\n\n```json\n{\n"
+		if !strings.Contains(md, head) || !strings.HasSuffix(md, "}\n```\n\n</details>\n") {
+			t.Fatalf("%s: expected the source spec appendix at the end, got:\n%s", fixture, md)
+		}
+		if !strings.Contains(md[strings.Index(md, head):], `"title": "`) {
+			t.Fatalf("%s: expected the appendix to hold the spec, got:\n%s", fixture, md)
+		}
+
+		md, err = ToMarkdown(data, Options{Format: FormatJSON, AppendRawSpec: true, MaxRawSpecBytes: 100})
+		if err != nil {
+			t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+		}
+		if !strings.Contains(md, "is larger than the 100-byte limit and is not included.\n") || strings.Contains(md, "<summary>Source spec") {
+			t.Fatalf("%s: expected an oversized spec to be left out with a note, got:\n%s", fixture, md)
+		}
+	}
+
+	// YAML input is included as the JSON it was normalized to.
+	md, err := ToMarkdown([]byte("openapi: 3.0.3\ninfo:\n  title: YAML API\n  version: 1.0.0\npaths: {}\n"), Options{AppendRawSpec: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(md, `"title": "YAML API"`) {
+		t.Fatalf("expected the normalized JSON of YAML input, got:\n%s", md)
+	}
+}
+
+func TestPreferOperationIdAnchors(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.operation-anchors.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	opts := Options{Format: FormatJSON, PreferOperationIdAnchors: true, PrimaryTagOnly: true}
+	md, err := ToMarkdown(data, opts)
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"<a id=\"listpets\"></a>\n#### GET /pets\n",
+		// No operationId: method and path.
+		"<a id=\"post-pets\"></a>\n#### POST /pets\n",
+		// A repeated operationId is made unique.
+		"<a id=\"listpets-1\"></a>\n#### GET /v2/pets\n",
+		"**Also in this tag**\n- [GET /pets](#listpets) — under pets\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got:\n%s", want, md)
+		}
+	}
+
+	opts.OutputFormat = OutputJSONL
+	index, err := ToMarkdown(data, opts)
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{`"anchor":"#listpets"`, `"anchor":"#post-pets"`, `"anchor":"#listpets-1"`} {
+		if !strings.Contains(index, want) {
+			t.Fatalf("expected %s in the JSONL index, got:\n%s", want, index)
+		}
+	}
+
+	// Without the option, headings keep their method and path anchors.
+	md, err = ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(md, "<a id=") {
+		t.Fatalf("expected no explicit anchors by default, got:\n%s", md)
+	}
+}
+
+func TestCodeSamples(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.code-samples.json", "testdata/v3.code-samples.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "**Code Samples**\n" +
+				"Python (requests)\n" +
+				"```python\nimport requests\n\nresp = requests.get(\"https://api.example.com/pets\")\nprint(resp.json())\n```\n" +
+				"JavaScript\n" +
+				"```javascript\nconst resp = await fetch(\"https://api.example.com/pets\");\nconsole.log(await resp.json());\n```\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected code samples under the operation, got:\n%s", md)
+			}
+			if strings.Contains(md, "curl https://") {
+				t.Fatalf("expected only the default extension to be rendered, got:\n%s", md)
+			}
+
+			custom, err := ToMarkdown(data, Options{Format: FormatJSON, CodeSamplesKey: "x-snippets"})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(custom, "**Code Samples**\ncurl\n```sh\ncurl https://api.example.com/pets\n```\n") {
+				t.Fatalf("expected code samples from the configured key, got:\n%s", custom)
+			}
+		})
+	}
+}
+
+func TestErrorSchemaSection(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.error-schema.json", "testdata/v3.error-schema.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, ErrorSchemaName: "Error"})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "## Errors\n" +
+				"Error responses share the [Error](#error) schema, documented here once.\n\n" +
+				"Returned by every failing request.\n\n" +
+				"| Field | Type | Required | Description |\n|---|---|---|---|\n" +
+				"| `code` | integer | yes | Application error code. |\n" +
+				"| `details` | array<string> | no |  |\n" +
+				"| `message` | string | yes | Human-readable message. |\n\n" +
+				"| Operation | Status | Description |\n|---|---|---|\n" +
+				"| [GET /pets](#get-pets) | default | Unexpected error |\n" +
+				"| [GET /pets/{id}](#get-petsid) | 404 | Pet not found |\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected an Errors section, got:\n%s", md)
+			}
+			if got := strings.Count(md, "(see [Errors](#errors))"); got != 2 {
+				t.Fatalf("expected 2 responses to point at Errors, got %d:\n%s", got, md)
+			}
+
+			for _, opts := range []Options{{Format: FormatJSON}, {Format: FormatJSON, ErrorSchemaName: "Missing"}} {
+				plain, err := ToMarkdown(data, opts)
+				if err != nil {
+					t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+				}
+				if strings.Contains(plain, "## Errors") || strings.Contains(plain, "see [Errors]") {
+					t.Fatalf("expected no Errors section for ErrorSchemaName %q, got:\n%s", opts.ErrorSchemaName, plain)
+				}
+			}
+		})
+	}
+}
+
+func TestSwagger2ServersPerScheme(t *testing.T) {
+	data, err := os.ReadFile("testdata/v2.schemes.json")
+	if err != nil {
+		t.Fatalf("failed to read v2.schemes.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v2.schemes.json) returned error: %v", err)
+	}
+	if !strings.Contains(md, "## Servers\n- http://api.example.com/v1\n- https://api.example.com/v1\n") {
+		t.Fatalf("expected one server line per scheme, got:\n%s", md)
+	}
+
+	// Without schemes, the single base URL falls back to http.
+	noSchemes := strings.Replace(string(data), `"schemes": ["http", "https"],`, "", 1)
+	md, err = ToMarkdown([]byte(noSchemes), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(md, "## Servers\n- http://api.example.com/v1\n\n") {
+		t.Fatalf("expected a single http server line, got:\n%s", md)
+	}
+}
+
+func TestGroupDeprecatedParameters(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.deprecated-parameters.json", "testdata/v3.deprecated-parameters.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			deprecated := "**Deprecated Parameters**\n" +
+				"- query `page` (integer) (deprecated) — Use cursor instead.\n" +
+				"- header `X-Legacy-Client` (string) (deprecated)\n"
+			active := "- query `limit` (integer) — Page size.\n- query `cursor` (string)\n\n"
+
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, GroupDeprecatedParameters: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(md, "**Parameters**\n"+active+deprecated) {
+				t.Fatalf("expected deprecated parameters after the active ones, got:\n%s", md)
+			}
+
+			grouped, err := ToMarkdown(data, Options{Format: FormatJSON, GroupDeprecatedParameters: true, GroupParametersByLocation: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(grouped, "**Query Parameters**\n"+active+deprecated) || strings.Contains(grouped, "**Header Parameters**") {
+				t.Fatalf("expected deprecated parameters after the location groups, got:\n%s", grouped)
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(plain, "**Deprecated Parameters**") || !strings.Contains(plain, "- query `limit` (integer) — Page size.\n- query `page` (integer) (deprecated)") {
+				t.Fatalf("expected deprecated parameters in place by default, got:\n%s", plain)
+			}
+		})
+	}
+}
+
+func TestWriteOnlyRequestFields(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.write-only.json")
+	if err != nil {
+		t.Fatalf("failed to read v3.write-only.json: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown(v3.write-only.json) returned error: %v", err)
+	}
+	want := "**Request Body**\n" +
+		"- application/json — schema: $ref:CreateUser\n" +
+		"  - `password` (write-only)\n" +
+		"  - `pin` (write-only)\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected write-only fields under the request body, got:\n%s", md)
+	}
+	if got := strings.Count(md, "(write-only)"); got != 2 {
+		t.Fatalf("expected write-only notes only in the request body, got %d:\n%s", got, md)
+	}
+}
+
+func TestMultiDocumentYAML(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.multi-doc.yaml")
+	if err != nil {
+		t.Fatalf("failed to read v3.multi-doc.yaml: %v", err)
+	}
+
+	_, err = ToMarkdown(data, Options{Format: FormatYAML})
+	if err == nil || !strings.Contains(err.Error(), "documents 2, 3 look like specs") || !strings.Contains(err.Error(), "DocumentIndex") {
+		t.Fatalf("expected an error naming the candidate documents, got %v", err)
+	}
+
+	for index, title := range map[int]string{2: "# Multi-document API\n", 3: "# Multi-document API (next)\n"} {
+		md, err := ToMarkdown(data, Options{Format: FormatAuto, DocumentIndex: index})
+		if err != nil {
+			t.Fatalf("ToMarkdown(DocumentIndex: %d) returned error: %v", index, err)
+		}
+		if !strings.HasPrefix(md, title) {
+			t.Fatalf("expected document %d to be rendered, got:\n%s", index, md)
+		}
+	}
+
+	if _, err := ToMarkdown(data, Options{Format: FormatYAML, DocumentIndex: 4}); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("expected an out-of-range error, got %v", err)
+	}
+
+	// With a single spec-looking document, it is picked automatically.
+	first := data[:strings.LastIndex(string(data), "---")]
+	md, err := ToMarkdown(first, Options{Format: FormatAuto})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.HasPrefix(md, "# Multi-document API\n") || !strings.Contains(md, "#### GET /pets\n") {
+		t.Fatalf("expected the spec document to be picked, got:\n%s", md)
+	}
+}
+
+func TestByteFormatNotes(t *testing.T) {
+	cases := map[string][]string{
+		"testdata/v2.binary-upload.json": {
+			"**Request Body**\n- `body` — binary upload (required)\n",
+			"- 200 — The file (binary body)\n- 203 — The file, base64-encoded (base64-encoded bytes)\n",
+			"- `avatar` (base64-encoded bytes) — PNG image.\n",
+		},
+		"testdata/v3.binary-upload.json": {
+			"**Request Body** — schemas differ by content type\n- application/octet-stream — binary upload\n- multipart/form-data — schema: $ref:FileUpload\n",
+			"  - application/octet-stream — binary body (format: binary)\n  - text/plain — base64-encoded bytes (format: byte)\n",
+			"- `file` (binary file)\n- `name` (string)\n",
+			"- `avatar` (base64-encoded bytes) — PNG image.\n",
+		},
+	}
+	for fixture, wants := range cases {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			for _, want := range wants {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+			if strings.Contains(md, "(binary)") || strings.Contains(md, "(byte)") {
+				t.Fatalf("expected no raw string formats, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Run("json passthrough", func(t *testing.T) {
+		in := "\xef\xbb\xbf{\n  \"openapi\": \"3.0.3\",\n  \"info\": { \"title\": \"T\", \"version\": \"1\" }\n}\n"
+		out, err := Normalize([]byte(in), FormatAuto)
+		if err != nil {
+			t.Fatalf("Normalize returned error: %v", err)
+		}
+		if want := `{"openapi":"3.0.3","info":{"title":"T","version":"1"}}`; string(out) != want {
+			t.Fatalf("expected %s, got %s", want, out)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		in := "openapi: 3.0.3\n" +
+			"base: &id\n  type: string\n  format: uuid\n" +
+			"petId:\n  <<: *id\n  description: Pet ID.\n"
+		for _, format := range []InputFormat{FormatAuto, FormatYAML} {
+			out, err := Normalize([]byte(in), format)
+			if err != nil {
+				t.Fatalf("Normalize(%s) returned error: %v", format, err)
+			}
+			want := `{"base":{"format":"uuid","type":"string"},"openapi":"3.0.3","petId":{"description":"Pet ID.","format":"uuid","type":"string"}}`
+			if string(out) != want {
+				t.Fatalf("Normalize(%s): expected %s, got %s", format, want, out)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tc := range []struct {
+			format InputFormat
+			in     string
+		}{
+			{FormatAuto, "paths: [unclosed"},
+			{FormatYAML, "paths: [unclosed"},
+			{FormatJSON, "openapi: 3.0.3"},
+		} {
+			if out, err := Normalize([]byte(tc.in), tc.format); err == nil {
+				t.Fatalf("Normalize(%s, %q): expected an error, got %s", tc.format, tc.in, out)
+			}
+		}
+	})
+}
+
+func TestSchemaUsageBackrefs(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.schema-usage.json", "testdata/v3.schema-usage.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, SchemaUsageBackrefs: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			users := "**Used by**\n- [GET /users](#get-users)\n- [POST /users](#post-users)\n"
+			// Address is only reached through User.address.
+			for _, want := range []string{
+				"- `city` (string)\n\n" + users,
+				"- `name` (string)\n\n" + users,
+				"### OrderStatus\n**Used by**\n- [GET /orders](#get-orders)\n",
+			} {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+			if got := strings.Count(md, "**Used by**"); got != 3 {
+				t.Fatalf("expected 3 Used by lists (none for Unused), got %d:\n%s", got, md)
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(plain, "**Used by**") {
+				t.Fatalf("expected no back-references unless SchemaUsageBackrefs is set")
+			}
+		})
+	}
+}
+
+func TestExcludeInternal(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.internal.json", "testdata/v3.internal.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, ExcludeInternal: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			for _, want := range []string{"#### GET /pets", "#### DELETE /pets/{id}", "### Pet\n", "### Tag\n", "### Standalone\n"} {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+			// ReindexJob and DebugInfo are only used by internal operations.
+			for _, unwanted := range []string{"/admin/reindex", "/debug", "### admin", "ReindexJob", "DebugInfo", "InternalAudit"} {
+				if strings.Contains(md, unwanted) {
+					t.Fatalf("expected %q to be excluded, got:\n%s", unwanted, md)
+				}
+			}
+
+			custom, err := ToMarkdown(data, Options{Format: FormatJSON, ExcludeInternal: true, InternalKey: "x-private"})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(custom, "DELETE /pets/{id}") || !strings.Contains(custom, "POST /admin/reindex") {
+				t.Fatalf("expected InternalKey to select the flag, got:\n%s", custom)
+			}
+
+			index, err := ToMarkdown(data, Options{Format: FormatJSON, OutputFormat: OutputJSONL, ExcludeInternal: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if got := strings.Count(index, "\n"); got != 2 || strings.Contains(index, "/admin/reindex") {
+				t.Fatalf("expected two public JSONL records, got:\n%s", index)
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			for _, want := range []string{"POST /admin/reindex", "GET /debug", "### ReindexJob", "### InternalAudit"} {
+				if !strings.Contains(plain, want) {
+					t.Fatalf("expected %q unless ExcludeInternal is set, got:\n%s", want, plain)
+				}
+			}
+		})
+	}
+}
+
+func TestOAuthFlowsOpenAPI3(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.oauth-flows.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "- petAuth — type=oauth2\n" +
+		"  - clientCredentials flow\n" +
+		"    - Token URL: https://auth.example.com/token\n" +
+		"    - Scopes:\n" +
+		"      - `pets:admin`\n" +
+		"  - authorizationCode flow\n" +
+		"    - Authorization URL: https://auth.example.com/authorize\n" +
+		"    - Token URL: https://auth.example.com/token\n" +
+		"    - Refresh URL: https://auth.example.com/refresh\n" +
+		"    - Scopes:\n" +
+		"      - `pets:read` — Read pets\n" +
+		"      - `pets:write` — Modify pets\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected OAuth2 flows %q, got:\n%s", want, md)
+	}
+}
+
+func TestToMarkdownContext(t *testing.T) {
+	want, err := ToMarkdown([]byte(minimalSwagger2JSON), Options{})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	got, err := ToMarkdownContext(context.Background(), []byte(minimalSwagger2JSON), Options{})
+	if err != nil || got != want {
+		t.Fatalf("ToMarkdownContext = (%q, %v), want the ToMarkdown rendering", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ToMarkdownContext(ctx, []byte(minimalSwagger2JSON), Options{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := ConvertAllContext(ctx, []byte(minimalSwagger2JSON), Options{}, []OutputFormat{OutputMarkdown}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled from ConvertAllContext, got %v", err)
+	}
+
+	// Rendering stops at the next operation or schema once ctx is done, so
+	// an abandoned conversion does not run on in the background.
+	for _, fixture := range []string{"testdata/v2.json", "testdata/v3.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", fixture, err)
+		}
+		for _, f := range []OutputFormat{OutputMarkdown, OutputJSONL} {
+			if _, err := ToMarkdown(data, Options{Format: FormatJSON, OutputFormat: f, ctx: ctx}); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected %s rendering of %s to stop with context.Canceled, got %v", f, fixture, err)
+			}
+		}
+	}
+
+	// A panic on the conversion goroutine comes back as an error.
+	if _, err := ToMarkdownContext(context.Background(), []byte(minimalSwagger2JSON), Options{Renderer: panicRenderer{}}); err == nil || !strings.Contains(err.Error(), "conversion panic") {
+		t.Fatalf("expected a conversion panic error, got %v", err)
+	}
+}
+
+// panicRenderer is a Renderer that panics.
+type panicRenderer struct{}
+
+func (panicRenderer) RenderOpenAPI3(*openapi3.T, []byte, Options) (string, error) { panic("boom") }
+
+func (panicRenderer) RenderSwagger2(*spec.Swagger, []byte, Options) (string, error) { panic("boom") }
+
+func TestRequestBodySchemasDiffer(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.body-schemas.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "**Request Body** — schemas differ by content type\n" +
+		"- application/json — schema: $ref:User\n" +
+		"- application/x-www-form-urlencoded — schema: $ref:UserForm\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected %q, got:\n%s", want, md)
+	}
+	// PUT sends the same schema as JSON and XML.
+	if got := strings.Count(md, "schemas differ by content type"); got != 1 {
+		t.Fatalf("expected one differing body, got %d:\n%s", got, md)
+	}
+}
+
+func TestEmitAnchorsForSchemas(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.oneof-body.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	for _, style := range []SlugStyle{SlugGitHub, SlugMkDocs} {
+		t.Run(string(style), func(t *testing.T) {
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, SlugStyle: style, EmitAnchorsForSchemas: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown returned error: %v", err)
+			}
+			slug, _ := slugFunc(style)
+			anchor := slug("CreateUser")
+			if want := "<a id=\"" + anchor + "\"></a>\n### CreateUser\n"; !strings.Contains(md, want) {
+				t.Fatalf("expected schema anchor %q, got:\n%s", want, md)
+			}
+			if want := "[CreateUser](#" + anchor + ")"; !strings.Contains(md, want) {
+				t.Fatalf("expected link %q to the same slug, got:\n%s", want, md)
+			}
+		})
+	}
+
+	plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(plain, "<a id=") {
+		t.Fatalf("expected no explicit anchors by default")
+	}
+}
+
+func TestDeprecationReason(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.deprecation-reason.json", "testdata/v3.deprecation-reason.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			for _, want := range []string{
+				"#### GET /pets\n**DEPRECATED — use /v2/pets instead**\n\n**List pets**\n",
+				"#### DELETE /pets/{id}\n**DEPRECATED — archive pets with POST /pets/{id}/archive**\n",
+				"- `name` (string) (deprecated — use fullName instead)",
+				"- `tag` (string) (deprecated)\n",
+			} {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+			if got := strings.Count(md, "**DEPRECATED"); got != 2 {
+				t.Fatalf("expected badges on the two deprecated operations only, got %d:\n%s", got, md)
+			}
+		})
+	}
+}
+
+func TestResponseLinks(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.links.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"  - Link `getOwner` → operation in `common.yaml#/paths/~1owners~1{ownerId}/get` (ownerId: `$response.body#/ownerId`) — Owners are served by the common API.\n",
+		"  - Link `getPet` → [GET /pets/{id}](#get-petsid) (id: `$response.body#/id`) — Fetch the new pet.\n",
+		"  - Link `getPetByRef` → [GET /pets/{id}](#get-petsid) (id: `$response.body#/id`)\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got:\n%s", want, md)
+		}
+	}
+
+	prefixed, err := ToMarkdown(data, Options{Format: FormatJSON, AnchorPrefix: "links-"})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(prefixed, "[GET /pets/{id}](#links-get-petsid)") {
+		t.Fatalf("expected links to follow AnchorPrefix, got:\n%s", prefixed)
+	}
+}
+
+func TestMethodBadges(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.links.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON, MethodBadges: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	for _, want := range []string{
+		"<a id=\"post-pets\"></a>\n#### 🟡 POST /pets\n",
+		"<a id=\"get-petsid\"></a>\n#### 🟢 GET /pets/{id}\n",
+		// Links keep the badge-free anchor.
+		"[GET /pets/{id}](#get-petsid)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q, got:\n%s", want, md)
+		}
+	}
+
+	mono, err := ToMarkdown(data, Options{Format: FormatJSON, MethodBadges: true, MethodBadgeMap: map[string]string{"GET": "[GET]", "POST": "[POST]"}})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(mono, "<a id=\"post-pets\"></a>\n#### [POST] POST /pets\n") || !strings.Contains(mono, "#### [GET] GET /pets/{id}\n") {
+		t.Fatalf("expected MethodBadgeMap badges, got:\n%s", mono)
+	}
+
+	plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(plain, "\n#### POST /pets\n") || strings.Contains(plain, "<a id=") {
+		t.Fatalf("expected plain headings without badges by default, got:\n%s", plain)
+	}
+}
+
+func TestExampleContainingRef(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.example-ref.json", "testdata/v3.example-ref.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			for _, want := range []string{
+				"Response example (200, application/json) (contains unresolved $ref)\n```json\n",
+				"Example (contains unresolved $ref)\n```json\n{\n  \"name\": \"Rex\",\n  \"owner\": {\n    \"$ref\": ",
+			} {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+			if got := strings.Count(md, "(contains unresolved $ref)"); got != 2 {
+				t.Fatalf("expected the note on the two ref-holding examples only, got %d:\n%s", got, md)
+			}
+		})
+	}
+}
+
+func TestDefaultResponseExamples(t *testing.T) {
+	wantSchema := map[string]string{
+		"testdata/v2.default-response.json": "- default — applies to all undocumented status codes — Unexpected error (schema: Problem)\n",
+		"testdata/v3.default-response.json": "- default — applies to all undocumented status codes — Unexpected error\n  - application/json — schema: $ref:Problem\n",
+	}
+	for fixture, schema := range wantSchema {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := schema + "Response example (default, application/json)\n```json\n{\n  \"code\": 500,\n  \"message\": \"internal error\"\n}\n```\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected %q, got:\n%s", want, md)
+			}
+			if !strings.Contains(md, "Response example (200, application/json)\n") {
+				t.Fatalf("expected numbered response examples to remain, got:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestAnnotatePathParams(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.path-params.json", "testdata/v3.path-params.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, AnnotatePathParams: true})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			for _, want := range []string{
+				"<a id=\"get-petspetid\"></a>\n#### GET /pets/{petId: integer}\n",
+				// ownerId comes from the path item; name has no type.
+				"<a id=\"get-ownersowneridpetsname\"></a>\n#### GET /owners/{ownerId: string}/pets/{name}\n",
+				// fileId is not declared at all.
+				"<a id=\"get-filesfileid\"></a>\n#### GET /files/{fileId}\n",
+			} {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(plain, "\n#### GET /pets/{petId}\n") {
+				t.Fatalf("expected bare placeholders by default, got:\n%s", plain)
+			}
+		})
+	}
+}
+
+func TestMultiParagraphInfoDescription(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.info-description.json", "testdata/v3.info-description.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			want := "## Overview\nThe Described API manages pets.\n\nIt supports:\n\n- listing pets\n- **adopting** a pet\n\n" +
+				"See the [guide](https://example.com/guide) for details.\n\n- API Version: 1.0.0\n"
+			if !strings.Contains(md, want) {
+				t.Fatalf("expected the description as a block, got:\n%s", md)
+			}
+			if strings.Contains(md, "- Description:") {
+				t.Fatalf("expected no Description bullet for a multi-line description")
+			}
+		})
+	}
+
+	data, err := os.ReadFile("testdata/v3.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if !strings.Contains(md, "- Description: Small but complete OpenAPI 3.0 spec for testing.\n") {
+		t.Fatalf("expected a single-line description to stay a bullet, got:\n%s", md)
+	}
+}
+
+func TestMediaTypeFilter(t *testing.T) {
+	for _, fixture := range []string{"testdata/v2.media-filter.json", "testdata/v3.media-filter.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", fixture, err)
+			}
+			md, err := ToMarkdown(data, Options{Format: FormatJSON, MediaTypeFilter: []string{"Application/JSON"}})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if strings.Contains(md, "application/xml") || strings.Contains(md, "<pet>") {
+				t.Fatalf("expected XML media types to be omitted, got:\n%s", md)
+			}
+			for _, want := range []string{
+				"Response example (201, application/json; charset=utf-8)\n",
+				"- " + noMatchingMediaTypes + "\n",
+			} {
+				if !strings.Contains(md, want) {
+					t.Fatalf("expected %q, got:\n%s", want, md)
+				}
+			}
+
+			plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+			if err != nil {
+				t.Fatalf("ToMarkdown(%s) returned error: %v", fixture, err)
+			}
+			if !strings.Contains(plain, "application/xml") || strings.Contains(plain, noMatchingMediaTypes) {
+				t.Fatalf("expected every media type without a filter, got:\n%s", plain)
+			}
+		})
+	}
+}
+
+func TestExampleComparisonTable(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3.example-comparison.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	md, err := ToMarkdown(data, Options{Format: FormatJSON, ExampleComparisonTable: true})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	want := "Request examples (application/json)\n\n" +
+		"| Key | A cat | A dog |\n|---|---|---|\n" +
+		"| `age` | - | `3` |\n" +
+		"| `indoor` | `true` | - |\n" +
+		"| `name` | `\"Tom\"` | `\"Rex\"` |\n" +
+		"| `species` | `\"cat\"` | `\"dog\"` |\n\n"
+	if !strings.Contains(md, want) {
+		t.Fatalf("expected comparison table %q, got:\n%s", want, md)
+	}
+	if strings.Contains(md, "Request example (cat, application/json)") {
+		t.Fatalf("expected compared examples not to be repeated as fences, got:\n%s", md)
+	}
+	// The response examples nest objects, so they keep their fences.
+	if !strings.Contains(md, "Response example (cat, 201, application/json)\n```json\n") || strings.Contains(md, "Response examples (") {
+		t.Fatalf("expected nested examples to fall back to fences, got:\n%s", md)
+	}
+
+	plain, err := ToMarkdown(data, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("ToMarkdown returned error: %v", err)
+	}
+	if strings.Contains(plain, "| Key |") || !strings.Contains(plain, "Request example (cat, application/json)") {
+		t.Fatalf("expected sequential fences by default, got:\n%s", plain)
+	}
+
+	// Pipes in example names and keys do not split cells.
+	var b bytes.Buffer
+	writeExampleComparison(&b, "Request examples", []namedExample{
+		{Name: "cat|dog", Value: map[string]any{"a|b": 1}},
+		{Name: "bird", Value: map[string]any{"a|b": 2}},
+	})
+	if want := "| Key | cat\\|dog | bird |\n|---|---|---|\n| `a\\|b` | `1` | `2` |\n"; !strings.Contains(b.String(), want) {
+		t.Fatalf("expected escaped names and keys %q, got:\n%s", want, b.String())
+	}
+
+	// Backticks in keys and values get a longer fence instead of ending
+	// the code span.
+	b.Reset()
+	writeExampleComparison(&b, "Request examples", []namedExample{
+		{Name: "cat", Value: map[string]any{"a`b": "x`y"}},
+		{Name: "dog", Value: map[string]any{"a`b": 2}},
+	})
+	if want := "| ``a`b`` | ``\"x`y\"`` | `2` |\n"; !strings.Contains(b.String(), want) {
+		t.Fatalf("expected backticks kept inside code spans %q, got:\n%s", want, b.String())
+	}
+}
+
+func TestOutputFiles(t *testing.T) {
+	rendered := map[OutputFormat]string{OutputMarkdown: "# md", OutputJSONL: "{}\n"}
+	got := OutputFiles("docs/api.md", rendered)
+	if len(got) != 2 || got["docs/api.md"] != "# md" || got["docs/api.jsonl"] != "{}\n" {
+		t.Fatalf("multi format OutputFiles = %v", got)
+	}
+	got = OutputFiles("docs/reference.txt", map[OutputFormat]string{OutputJSONL: "{}\n"})
+	if len(got) != 1 || got["docs/reference.txt"] != "{}\n" {
+		t.Fatalf("single format OutputFiles = %v", got)
+	}
+}
+
+func TestConvertDir(t *testing.T) {
+	dir := t.TempDir()
+	spec, err := os.ReadFile(filepath.Join("testdata", "v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{
+		"nested/api.json": spec,
+		"package.json":    []byte(`{"name": "docs"}`),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputs, skipped, err := ConvertDir(context.Background(), dir, Options{}, []OutputFormat{OutputMarkdown, OutputJSONL})
+	if err != nil {
+		t.Fatalf("ConvertDir returned error: %v", err)
+	}
+	if len(outputs) != 2 || !strings.HasPrefix(outputs["nested/api.md"], "# ") || outputs["nested/api.jsonl"] == "" {
+		t.Fatalf("expected nested/api.md and nested/api.jsonl, got %v", outputs)
+	}
+	if len(skipped) != 1 || skipped[0] != "package.json" {
+		t.Fatalf("expected package.json to be skipped, got %v", skipped)
+	}
+
+	// nested/api.yaml would also become nested/api.md.
+	if err := os.WriteFile(filepath.Join(dir, "nested", "api.yaml"), spec, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ConvertDir(context.Background(), dir, Options{}, []OutputFormat{OutputMarkdown})
+	if err == nil || !strings.Contains(err.Error(), "nested/api.json and nested/api.yaml both convert to nested/api.md") {
+		t.Fatalf("expected an error naming both sources of nested/api.md, got %v", err)
+	}
+}
+
+func TestRecoverSwagger2ResponsesLeavesSpec(t *testing.T) {
+	data, err := os.ReadFile("testdata/v2.odd-codes.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	s, err := parseSwagger2(data, Options{})
+	if err != nil {
+		t.Fatalf("parseSwagger2 returned error: %v", err)
+	}
+	recovered, unknown := recoverSwagger2Responses(data, s)
+	if _, ok := s.Paths.Paths["/things"].Get.Responses.StatusCodeResponses[200]; ok {
+		t.Fatalf("expected the parsed spec to be left without the padded 200 response")
+	}
+	op := recovered.Paths.Paths["/things"].Get
+	if _, ok := op.Responses.StatusCodeResponses[200]; !ok {
+		t.Fatalf("expected the padded 200 response to be restored in the copy")
+	}
+	if len(unknown[op]) != 1 || unknown[op][0].Key != "Success" {
+		t.Fatalf("expected Success keyed by the copied operation, got %v", unknown)
+	}
 }
 
 func min(a, b int) int {
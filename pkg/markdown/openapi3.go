@@ -2,8 +2,10 @@ package markdown
 
 import (
 	"bytes"
-	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -12,28 +14,221 @@ import (
 
 // OpenAPI 3.x markdown generation.
 
-func openAPI3ToMarkdown(data []byte, opts Options) (md string, err error) {
+// parseOpenAPI3 loads an OpenAPI 3.x document from normalized JSON.
+func parseOpenAPI3(data []byte, opts Options) (doc *openapi3.T, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("openapi3 conversion panic: %v", r)
-			md = ""
+			err = conversionPanic("openapi3", PhaseParse, r, opts)
+			doc = nil
 		}
 	}()
 
+	done := phaseTimer(opts, PhaseParse)
 	loader := openapi3.NewLoader()
-	doc, err := loader.LoadFromData(data)
+	if opts.BaseDir != "" || opts.RefFetcher != nil {
+		var location *url.URL
+		if location, err = documentLocation(opts); err != nil {
+			return nil, fmt.Errorf("parse openapi 3: %w", err)
+		}
+		loader.IsExternalRefsAllowed = true
+		loader.ReadFromURIFunc = readExternalRef(opts)
+		doc, err = loader.LoadFromDataWithPath(normalizeExclusiveBounds(data), location)
+	} else {
+		doc, err = loader.LoadFromData(normalizeExclusiveBounds(data))
+	}
 	if err != nil {
-		return "", fmt.Errorf("parse openapi 3: %w", err)
+		return nil, fmt.Errorf("parse openapi 3: %w", err)
 	}
 	if doc == nil {
-		return "", fmt.Errorf("parse openapi 3: loader returned nil document")
+		return nil, fmt.Errorf("parse openapi 3: loader returned nil document")
+	}
+	done()
+	return doc, nil
+}
+
+// validateOpenAPI3 validates doc unless opts.SkipValidation is set. Errors
+// only fail the conversion with opts.StrictValidation. It runs before any
+// renderer, so custom and JSONL output is validated like Markdown.
+func validateOpenAPI3(doc *openapi3.T, opts Options) (err error) {
+	if doc == nil || opts.SkipValidation {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = conversionPanic("openapi3", PhaseValidate, r, opts)
+		}
+	}()
+	done := phaseTimer(opts, PhaseValidate)
+	err = doc.Validate(opts.contextOrBackground())
+	done()
+	if err != nil && opts.StrictValidation {
+		return fmt.Errorf("validate openapi 3: %w", err)
+	}
+	return nil
+}
+
+// renderOpenAPI3 renders a loaded document that validateOpenAPI3 has already
+// checked. raw is the JSON the document was loaded from, or nil for
+// documents built in code.
+func renderOpenAPI3(doc *openapi3.T, raw []byte, opts Options) (md string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = conversionPanic("openapi3", PhaseRender, r, opts)
+			md = ""
+		}
+	}()
+
+	if doc == nil {
+		return "", fmt.Errorf("render openapi 3: nil document")
+	}
+	defer phaseTimer(opts, PhaseRender)()
+
+	components := renderedComponentsOpenAPI3(doc, opts)
+	d, err := documentElementsOpenAPI3(doc, components, opts)
+	if err != nil {
+		return "", err
+	}
+	slug, err := anchorFunc(opts)
+	if err != nil {
+		return "", err
+	}
+
+	schemaLink := func(name string) string {
+		if _, ok := components.Schemas[name]; ok && !opts.OmitSchemas {
+			return fmt.Sprintf("[%s](#%s)", name, slug(name))
+		}
+		return name
 	}
-	if !opts.SkipValidation {
-		_ = doc.Validate(context.Background())
+	// sharedLink links a $ref to a reusable parameter or request body to its
+	// entry in the matching reference section, when that section is rendered.
+	sharedLink := func(ref string) string {
+		name := refName(ref)
+		switch {
+		case strings.HasPrefix(ref, "#/components/parameters/") && opts.ReusableParameters,
+			strings.HasPrefix(ref, "#/components/requestBodies/") && opts.ReusableRequestBodies:
+			return fmt.Sprintf(" (shared: [%s](#%s))", name, slug(name))
+		}
+		return ""
 	}
 
-	var b bytes.Buffer
+	// scopeDesc looks up an OAuth2 scope's description in the named
+	// scheme's flows, for operation security requirements.
+	scopeDesc := func(scheme, scope string) string {
+		ss := components.SecuritySchemes[scheme]
+		if ss == nil || ss.Value == nil || ss.Value.Flows == nil {
+			return ""
+		}
+		f := ss.Value.Flows
+		for _, flow := range []*openapi3.OAuthFlow{f.Implicit, f.Password, f.ClientCredentials, f.AuthorizationCode} {
+			if flow != nil && flow.Scopes[scope] != "" {
+				return flow.Scopes[scope]
+			}
+		}
+		return ""
+	}
 
+	// errorSchema is the schema the Errors section documents, or nil.
+	errorSchema := components.Schemas[opts.ErrorSchemaName]
+	if opts.ErrorSchemaName == "" || errorSchema == nil || errorSchema.Value == nil {
+		errorSchema = nil
+	}
+	// errorsNote returns the "(see Errors)" note for a response schema that
+	// refers to errorSchema, or "".
+	errorsNote := func(ref *openapi3.SchemaRef) string {
+		if errorSchema == nil || ref == nil || refName(ref.Ref) != opts.ErrorSchemaName {
+			return ""
+		}
+		return fmt.Sprintf(" (see [Errors](#%s))", slug("Errors"))
+	}
+
+	// headersFor lists the headers an operation requires, for
+	// opts.RequiredHeadersSummary.
+	headersFor := func(ref openAPI3Op) []string {
+		if !opts.RequiredHeadersSummary {
+			return nil
+		}
+		reqs := doc.Security
+		if ref.Op.Security != nil {
+			reqs = *ref.Op.Security
+		}
+		headerOf := func(scheme string) string {
+			ss := components.SecuritySchemes[scheme]
+			if ss == nil || ss.Value == nil || ss.Value.Type != "apiKey" || ss.Value.In != "header" {
+				return ""
+			}
+			return ss.Value.Name
+		}
+		// An operation parameter replaces a path-level one of the same name.
+		var params []string
+		own := map[string]bool{}
+		for _, pr := range ref.Op.Parameters {
+			if pr != nil && pr.Value != nil && pr.Value.In == "header" {
+				own[strings.ToLower(pr.Value.Name)] = true
+			}
+		}
+		for _, pr := range ref.PathItem.Parameters {
+			if pr != nil && pr.Value != nil && pr.Value.In == "header" && pr.Value.Required && !own[strings.ToLower(pr.Value.Name)] {
+				params = append(params, pr.Value.Name)
+			}
+		}
+		for _, pr := range ref.Op.Parameters {
+			if pr != nil && pr.Value != nil && pr.Value.In == "header" && pr.Value.Required {
+				params = append(params, pr.Value.Name)
+			}
+		}
+		return requiredHeaders(securityRequirementsOpenAPI3(reqs), headerOf, params)
+	}
+
+	operations := make([]openAPI3Op, len(d.operations))
+	for i, op := range d.operations {
+		operations[i] = op.source.(openAPI3Op)
+	}
+	m := &openAPI3Markdown{
+		doc:         doc,
+		raw:         raw,
+		opts:        opts,
+		slug:        slug,
+		components:  components,
+		operations:  operations,
+		errorSchema: errorSchema,
+		rc: &openAPI3RenderContext{
+			opts:       opts,
+			schemaLink: schemaLink,
+			sharedLink: sharedLink,
+			scopeDesc:  scopeDesc,
+			errorsNote: errorsNote,
+			linkTarget: func(l *openapi3.Link) string { return linkTargetOpenAPI3(l, operations) },
+			headers:    headersFor,
+		},
+	}
+	return walkElements(d, m, opts)
+}
+
+// openAPI3Markdown is the built-in Markdown output of an OpenAPI 3 document,
+// written element by element as walkElements visits them. The hooks write
+// to the *bytes.Buffer walkElements renders into.
+type openAPI3Markdown struct {
+	doc        *openapi3.T
+	raw        []byte
+	opts       Options
+	slug       func(string) string
+	components *openapi3.Components
+	// operations are every rendered operation, with its anchor.
+	operations  []openAPI3Op
+	rc          *openAPI3RenderContext
+	errorSchema *openapi3.SchemaRef
+	// errorsWritten records that the Errors section, which sits between the
+	// endpoints and the schemas, is written.
+	errorsWritten bool
+	// users and rawSchemas feed the Schemas section.
+	users      map[string][]operationReference
+	rawSchemas map[string]json.RawMessage
+}
+
+// Overview writes everything before the first tag section: the overview,
+// authentication, servers, and tags, then the Endpoints by Tag heading.
+func (m *openAPI3Markdown) Overview(w io.Writer, _ OverviewElement) error {
+	b, doc, opts, components := w.(*bytes.Buffer), m.doc, m.opts, m.components
 	// Overview
 	title := "-"
 	desc := ""
@@ -48,245 +243,308 @@ func openAPI3ToMarkdown(data []byte, opts Options) (md string, err error) {
 	}
 	if doc.Info != nil && doc.Info.Version != "" {
 		version = doc.Info.Version
-	} else if doc.OpenAPI != "" {
-		version = doc.OpenAPI
 	}
-	fmt.Fprintf(&b, "# %s\n\n", title)
-	fmt.Fprintf(&b, "## Overview\n")
-	fmt.Fprintf(&b, "- Version: %s\n", version)
-	if desc != "" {
-		fmt.Fprintf(&b, "- Description: %s\n", desc)
+	specVersion := "-"
+	if doc.OpenAPI != "" {
+		specVersion = doc.OpenAPI
 	}
+	if opts.IncludeBranding && doc.Info != nil {
+		writeLogo(b, doc.Info.Extensions)
+	}
+	fmt.Fprintf(b, "# %s\n\n", title)
+	fmt.Fprintf(b, "## Overview\n")
+	writeDescriptionBlock(b, desc)
+	fmt.Fprintf(b, "- API Version: %s\n", version)
+	fmt.Fprintf(b, "- OpenAPI Version: %s\n", specVersion)
+	writeDescriptionBullet(b, desc)
 	if doc.Info != nil && doc.Info.Contact != nil {
 		if doc.Info.Contact.Name != "" {
-			fmt.Fprintf(&b, "- Contact: %s\n", doc.Info.Contact.Name)
+			fmt.Fprintf(b, "- Contact: %s\n", doc.Info.Contact.Name)
 		}
 		if doc.Info.Contact.Email != "" {
-			fmt.Fprintf(&b, "- Contact Email: %s\n", doc.Info.Contact.Email)
+			fmt.Fprintf(b, "- Contact Email: %s\n", doc.Info.Contact.Email)
 		}
 	}
 	if doc.Info != nil && doc.Info.License != nil && doc.Info.License.Name != "" {
-		fmt.Fprintf(&b, "- License: %s\n", doc.Info.License.Name)
+		fmt.Fprintf(b, "- License: %s\n", doc.Info.License.Name)
+	}
+
+	if opts.IncludeChangelog {
+		writeChangelog(b, doc.Extensions, opts.ChangelogKey)
 	}
 
 	// Authentication (security schemes)
-	fmt.Fprintf(&b, "\n## Authentication\n")
-	if len(doc.Components.SecuritySchemes) == 0 {
-		fmt.Fprintf(&b, "- None defined\n")
+	if len(components.SecuritySchemes) == 0 {
+		writeEmptySection(b, "Authentication", opts)
 	} else {
-		names := make([]string, 0, len(doc.Components.SecuritySchemes))
-		for name := range doc.Components.SecuritySchemes {
+		fmt.Fprintf(b, "\n## Authentication\n")
+		names := make([]string, 0, len(components.SecuritySchemes))
+		for name := range components.SecuritySchemes {
 			names = append(names, name)
 		}
 		sort.Strings(names)
 		for _, name := range names {
-			ref := doc.Components.SecuritySchemes[name]
+			ref := components.SecuritySchemes[name]
 			if ref == nil || ref.Value == nil {
 				continue
 			}
 			ss := ref.Value
-			line := fmt.Sprintf("- %s — type=%s", name, ss.Type)
+			fmt.Fprintf(b, "- %s — type=%s", name, ss.Type)
 			if ss.Scheme != "" {
-				line += fmt.Sprintf(", scheme=%s", ss.Scheme)
+				fmt.Fprintf(b, ", scheme=%s", ss.Scheme)
 			}
 			if ss.Name != "" {
-				line += fmt.Sprintf(", name=%s", ss.Name)
+				fmt.Fprintf(b, ", name=%s", ss.Name)
 			}
 			if ss.In != "" {
-				line += fmt.Sprintf(", in=%s", ss.In)
+				fmt.Fprintf(b, ", in=%s", ss.In)
 			}
-			fmt.Fprintln(&b, line)
+			b.WriteByte('\n')
+			writeOAuthFlowsOpenAPI3(b, ss.Flows)
 		}
 	}
+	if doc.Security != nil {
+		fmt.Fprintf(b, "- Default security: %s\n", formatSecurityRequirements(securityRequirementsOpenAPI3(doc.Security), nil))
+	}
 
 	// Servers
-	fmt.Fprintf(&b, "\n## Servers\n")
 	if len(doc.Servers) == 0 {
-		fmt.Fprintf(&b, "- None defined\n")
+		writeEmptySection(b, "Servers", opts)
 	} else {
-		for _, s := range doc.Servers {
-			u := s.URL
-			if len(s.Variables) > 0 {
-				u += " {vars}"
-			}
-			fmt.Fprintf(&b, "- %s\n", u)
+		fmt.Fprintf(b, "\n## Servers\n")
+		primary, err := primaryServer(doc.Servers, opts)
+		if err != nil {
+			return err
+		}
+		for i, s := range doc.Servers {
+			writeOpenAPI3Server(b, s, i == primary && len(doc.Servers) > 1)
 		}
 	}
 
 	// Tags
-	fmt.Fprintf(&b, "\n## Tags\n")
 	if len(doc.Tags) == 0 {
-		fmt.Fprintf(&b, "- None defined\n")
+		writeEmptySection(b, "Tags", opts)
 	} else {
+		fmt.Fprintf(b, "\n## Tags\n")
 		for _, t := range doc.Tags {
 			if t.Description != "" {
-				fmt.Fprintf(&b, "- %s — %s\n", t.Name, t.Description)
+				fmt.Fprintf(b, "- %s — %s\n", t.Name, t.Description)
 			} else {
-				fmt.Fprintf(&b, "- %s\n", t.Name)
+				fmt.Fprintf(b, "- %s\n", t.Name)
 			}
 		}
 	}
 
-	// Endpoints by Tag
-	fmt.Fprintf(&b, "\n## Endpoints by Tag\n")
-
-	if doc.Paths == nil {
-		fmt.Fprintf(&b, "- None defined\n")
+	if doc.Paths == nil || (len(m.operations) == 0 && opts.OmitEmptySections) {
+		writeEmptySection(b, "Endpoints by Tag", opts)
 	} else {
-		pathMap := doc.Paths.Map()
-		pathKeys := make([]string, 0, len(pathMap))
-		for p := range pathMap {
-			pathKeys = append(pathKeys, p)
-		}
-		sort.Strings(pathKeys)
-
-		type opRef struct {
-			Method   string
-			Path     string
-			PathItem *openapi3.PathItem
-			Op       *openapi3.Operation
-		}
-		tagged := map[string][]opRef{}
-		untagged := []opRef{}
-
-		for _, p := range pathKeys {
-			pi := pathMap[p]
-			ops := []struct {
-				method string
-				op     *openapi3.Operation
-			}{
-				{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put}, {"DELETE", pi.Delete},
-				{"PATCH", pi.Patch}, {"OPTIONS", pi.Options}, {"HEAD", pi.Head}, {"TRACE", pi.Trace},
-			}
-			for _, it := range ops {
-				if it.op == nil {
-					continue
-				}
-				ref := opRef{Method: it.method, Path: p, PathItem: pi, Op: it.op}
-				if len(it.op.Tags) == 0 {
-					untagged = append(untagged, ref)
-					continue
-				}
-				for _, tag := range it.op.Tags {
-					tagged[tag] = append(tagged[tag], ref)
-				}
-			}
-		}
+		fmt.Fprintf(b, "\n## Endpoints by Tag\n")
+	}
+	return nil
+}
 
-		tagNames := make([]string, 0, len(tagged))
-		for name := range tagged {
-			tagNames = append(tagNames, name)
+// Section writes a tag's subheading, with its model index and the operations
+// listed under their primary tag, or starts the Schemas section.
+func (m *openAPI3Markdown) Section(w io.Writer, s SectionElement) error {
+	b, opts := w.(*bytes.Buffer), m.opts
+	if s.Kind == SectionSchemas {
+		m.writeErrors(b)
+		fmt.Fprintf(b, "\n## Schemas\n")
+		if opts.EmitModelDiagram {
+			writeModelDiagram(b, diagramClassesOpenAPI3(m.components.Schemas), opts.ModelDiagramMaxNodes)
 		}
-		sort.Strings(tagNames)
-		for _, name := range tagNames {
-			fmt.Fprintf(&b, "\n### %s\n", name)
-			for _, ref := range tagged[name] {
-				writeOpenAPI3Operation(&b, ref.Method, ref.Path, ref.PathItem, ref.Op)
-			}
+		if opts.IncludeRawSchema {
+			m.rawSchemas = rawObjectAt(m.raw, "components", "schemas")
 		}
+		if opts.SchemaUsageBackrefs {
+			m.users = schemaUsers(m.operations, func(o openAPI3Op, rs refSet) {
+				rs.addOperationOpenAPI3(o.PathItem, o.Op)
+			}, func(o openAPI3Op) operationReference {
+				return operationReference{Method: o.Method, Path: o.Path, Anchor: o.Anchor}
+			})
+		}
+		return nil
+	}
 
-		if len(untagged) > 0 {
-			fmt.Fprintf(&b, "\n### Untagged\n")
-			for _, ref := range untagged {
-				writeOpenAPI3Operation(&b, ref.Method, ref.Path, ref.PathItem, ref.Op)
-			}
+	writeSubheading(b, s.Name, opts)
+	if s.untagged {
+		return nil
+	}
+	if opts.TagModelIndex {
+		models := refSet{}
+		for _, op := range s.operations {
+			ref := op.source.(openAPI3Op)
+			models.addOperationOpenAPI3(ref.PathItem, ref.Op)
+		}
+		writeModelIndex(b, models.sorted(), m.slug, func(n string) bool {
+			_, ok := m.components.Schemas[n]
+			return ok && !opts.OmitSchemas
+		})
+	}
+	var references []operationReference
+	for _, op := range s.operations {
+		if opts.PrimaryTagOnly && op.Tags[0] != s.Name {
+			references = append(references, operationReference{op.Method, op.Path, op.Anchor, op.Tags[0]})
 		}
 	}
+	writeOperationReferences(b, references)
+	return nil
+}
 
-	// Schemas
-	if len(doc.Components.Schemas) > 0 {
-		fmt.Fprintf(&b, "\n## Schemas\n")
-		names := make([]string, 0, len(doc.Components.Schemas))
-		for name := range doc.Components.Schemas {
-			names = append(names, name)
+// Operation writes one operation.
+func (m *openAPI3Markdown) Operation(w io.Writer, op OperationElement) error {
+	writeOpenAPI3Operation(w.(*bytes.Buffer), op.source.(openAPI3Op), m.rc)
+	return nil
+}
+
+// Schema writes one component schema.
+func (m *openAPI3Markdown) Schema(w io.Writer, s SchemaElement) error {
+	b, opts, name, ref := w.(*bytes.Buffer), m.opts, s.Name, s.source.(*openapi3.SchemaRef)
+	users, rawSchemas := m.users, m.rawSchemas
+	writeSchemaHeading(b, name, opts)
+	if ref.Value != nil {
+		if ref.Value.Description != "" {
+			fmt.Fprintf(b, "%s\n\n", ref.Value.Description)
 		}
-		sort.Strings(names)
-		for _, name := range names {
-			ref := doc.Components.Schemas[name]
-			fmt.Fprintf(&b, "\n### %s\n", name)
-			if ref.Value != nil {
-				if ref.Value.Description != "" {
-					fmt.Fprintf(&b, "%s\n\n", ref.Value.Description)
+		var minProps, maxProps *int64
+		if ref.Value.MinProps > 0 {
+			v := int64(ref.Value.MinProps)
+			minProps = &v
+		}
+		if ref.Value.MaxProps != nil {
+			v := int64(*ref.Value.MaxProps)
+			maxProps = &v
+		}
+		if bounds := propertiesBounds(minProps, maxProps); bounds != "" {
+			fmt.Fprintf(b, "%s\n\n", bounds)
+		}
+		props, required := mergedPropertiesOpenAPI3(ref.Value)
+		if len(props) > 0 {
+			fmt.Fprintf(b, "**Properties**\n")
+			var propNames []string
+			for pn := range props {
+				propNames = append(propNames, pn)
+			}
+			sort.Strings(propNames)
+			for _, pn := range propNames {
+				ps := props[pn]
+				typ := nonEmpty(byteFormatNoteOpenAPI3(ps, binaryFileNote), typeOfSchemaRef(ps))
+				desc := ""
+				def := ""
+				enum := ""
+				var enumList []enumItem
+				if ps.Value != nil {
+					desc = strings.TrimSpace(ps.Value.Description)
+					if ps.Value.Default != nil {
+						def = fmt.Sprintf("%v", ps.Value.Default)
+					}
+					enumList = enumItems(ps.Value.Extensions, ps.Value.Enum, opts)
+					if enumList == nil {
+						enum = enumAsString(ps.Value.Enum)
+					}
+				}
+				req := ""
+				if contains(required, pn) {
+					req = " (required)"
 				}
-				if len(ref.Value.Properties) > 0 {
-					fmt.Fprintf(&b, "**Properties**\n")
-					var propNames []string
-					for pn := range ref.Value.Properties {
-						propNames = append(propNames, pn)
+				if ps.Value != nil && ps.Value.Deprecated {
+					req += deprecatedNote(deprecationReason(ps.Value.Description, ps.Value.Extensions))
+				}
+				title := ""
+				if ps.Value != nil {
+					title = ps.Value.Title
+				}
+				fmt.Fprintf(b, "- `%s` %s%s", pn, typeLabel(typ, title, ps.Ref != "", opts.PreferSchemaTitles), req)
+				if desc != "" {
+					fmt.Fprintf(b, " — %s", desc)
+				}
+				if def != "" {
+					fmt.Fprintf(b, " [default: %s]", literal(def))
+				}
+				if ps.Value != nil {
+					if bounds := numericBounds(ps.Value.Min, ps.Value.Max, ps.Value.ExclusiveMin, ps.Value.ExclusiveMax); bounds != "" {
+						fmt.Fprintf(b, " %s", bounds)
 					}
-					sort.Strings(propNames)
-					for _, pn := range propNames {
-						ps := ref.Value.Properties[pn]
-						typ := typeOfSchemaRef(ps)
-						desc := ""
-						def := ""
-						enum := ""
-						if ps.Value != nil {
-							desc = strings.TrimSpace(ps.Value.Description)
-							if ps.Value.Default != nil {
-								def = fmt.Sprintf("%v", ps.Value.Default)
-							}
-							if len(ps.Value.Enum) > 0 {
-								parts := make([]string, 0, len(ps.Value.Enum))
-								for _, v := range ps.Value.Enum {
-									parts = append(parts, fmt.Sprintf("%v", v))
-								}
-								enum = strings.Join(parts, ", ")
-							}
-						}
-						req := ""
-						if contains(ref.Value.Required, pn) {
-							req = " (required)"
-						}
-						line := fmt.Sprintf("- `%s` (%s)%s", pn, typ, req)
-						if desc != "" {
-							line += fmt.Sprintf(" — %s", desc)
-						}
-						if def != "" {
-							line += fmt.Sprintf(" [default: %s]", def)
-						}
-						if enum != "" {
-							line += fmt.Sprintf(" [enum: %s]", enum)
-						}
-						fmt.Fprintln(&b, line)
+					if constraints := constraintsOpenAPI3(ps.Value); constraints != "" {
+						fmt.Fprintf(b, " %s", constraints)
 					}
 				}
-				// Schema example
-				if ref.Value.Example != nil {
-					writeExampleFence(&b, "Example", "application/json", ref.Value.Example)
+				if enum != "" {
+					fmt.Fprintf(b, " [enum: %s]", enum)
+				}
+				b.WriteByte('\n')
+				if enumList != nil {
+					writeEnumItems(b, enumList)
 				}
 			}
 		}
+		writePatternProperties(b, ref.Value.Extensions)
+		if ap := ref.Value.AdditionalProperties; ap.Schema != nil {
+			writeAdditionalProperties(b, nil, typeOfSchemaRef(ap.Schema))
+		} else {
+			writeAdditionalProperties(b, ap.Has, "")
+		}
+		if opts.SplitReadWrite {
+			writeDirectionalRequired(b, required, func(pn string) (bool, bool) {
+				ps := props[pn]
+				if ps == nil || ps.Value == nil {
+					return false, false
+				}
+				return ps.Value.ReadOnly, ps.Value.WriteOnly
+			})
+		}
+		// Schema example
+		if ex := schemaExampleOpenAPI3(ref.Value); ex != nil {
+			writeExampleFence(b, "Example", "application/json", ex)
+		}
+	}
+	writeSchemaUsers(b, users[name])
+	if opts.IncludeRawSchema {
+		writeRawSchema(b, rawSchemas[name], ref)
 	}
+	return nil
+}
 
-	// Examples (basic): note where response content examples exist.
-	fmt.Fprintf(&b, "\n## Examples\n")
-	if doc.Paths == nil {
-		fmt.Fprintf(&b, "- None defined\n")
-	} else {
-		pathMap := doc.Paths.Map()
-		pathKeys := make([]string, 0, len(pathMap))
-		for p := range pathMap {
-			pathKeys = append(pathKeys, p)
-		}
-		sort.Strings(pathKeys)
-
-		for _, p := range pathKeys {
-			pi := pathMap[p]
-			ops := []struct {
-				method string
-				op     *openapi3.Operation
-			}{
-				{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put}, {"DELETE", pi.Delete},
-				{"PATCH", pi.Patch}, {"OPTIONS", pi.Options}, {"HEAD", pi.Head}, {"TRACE", pi.Trace},
-			}
-			for _, it := range ops {
-				op := it.op
-				if op == nil || op.Responses == nil {
+// writeErrors writes the Errors section once, when an error schema is set.
+func (m *openAPI3Markdown) writeErrors(b *bytes.Buffer) {
+	if m.errorsWritten || m.errorSchema == nil {
+		return
+	}
+	m.errorsWritten = true
+	opts := m.opts
+	writeErrorsSection(b, opts.ErrorSchemaName, m.errorSchema.Value.Description, errorFieldsOpenAPI3(m.errorSchema.Value), errorUsesOpenAPI3(m.operations, opts.ErrorSchemaName), m.rc.schemaLink, opts)
+}
+
+// finish writes what follows the schemas: the Errors section when there was
+// no Schemas section, the reusable component sections, the Examples index,
+// and the raw spec appendix.
+func (m *openAPI3Markdown) finish(b *bytes.Buffer) error {
+	doc, raw, opts, operations := m.doc, m.raw, m.opts, m.operations
+	m.writeErrors(b)
+	writeOpenAPI3ReusableSections(b, m.components, m.rc.schemaLink, opts)
+
+	// Examples index (opt-in): note where response content examples exist.
+	if opts.ExamplesSection {
+		if doc.Paths == nil {
+			writeEmptySection(b, "Examples", opts)
+		} else {
+			start := b.Len()
+			fmt.Fprintf(b, "\n## Examples\n")
+			body := b.Len()
+			for _, ref := range operations {
+				op := ref.Op
+				if op.Responses == nil {
 					continue
 				}
 				respMap := op.Responses.Map()
-				for code, r := range respMap {
+				codes := make([]string, 0, len(respMap))
+				for code := range respMap {
+					codes = append(codes, code)
+				}
+				sortResponseCodes(codes)
+				for _, key := range codes {
+					r := respMap[key]
+					code := strings.TrimSpace(key)
 					if r == nil || r.Value == nil {
 						continue
 					}
@@ -302,75 +560,189 @@ func openAPI3ToMarkdown(data []byte, opts Options) (md string, err error) {
 						}
 					}
 					if hasExample {
-						fmt.Fprintf(&b, "- %s %s %s — has inline examples\n", it.method, p, code)
+						fmt.Fprintf(b, "- %s %s %s — has inline examples\n", ref.Method, ref.Path, code)
 					}
 				}
 			}
+			if b.Len() == body && opts.OmitEmptySections {
+				b.Truncate(start)
+			}
 		}
 	}
 
-	return b.String(), nil
+	if opts.AppendRawSpec {
+		writeSourceSpecAppendix(b, raw, doc, opts)
+	}
+	return nil
+}
+
+// openAPI3RenderContext holds what every operation of one OpenAPI 3 render
+// shares.
+type openAPI3RenderContext struct {
+	opts Options
+	// schemaLink formats a component schema name, as a link when the Schemas
+	// section has a heading for it.
+	schemaLink func(name string) string
+	// sharedLink returns a " (shared: ...)" suffix for a reusable component
+	// ref, or "" when its reference section is not rendered.
+	sharedLink func(ref string) string
+	// scopeDesc looks up an OAuth2 scope's description.
+	scopeDesc func(scheme, scope string) string
+	// errorsNote returns the note that replaces a response schema's details
+	// when it is the error schema.
+	errorsNote func(*openapi3.SchemaRef) string
+	// linkTarget describes the operation a response link leads to.
+	linkTarget func(*openapi3.Link) string
+	// headers lists the headers an operation requires, for
+	// Options.RequiredHeadersSummary.
+	headers func(openAPI3Op) []string
 }
 
-func writeOpenAPI3Operation(b *bytes.Buffer, method, path string, pi *openapi3.PathItem, op *openapi3.Operation) {
-	fmt.Fprintf(b, "\n#### %s %s\n", method, path)
-	if op.Summary != "" {
-		fmt.Fprintf(b, "%s\n\n", op.Summary)
+// writeOpenAPI3Operation renders one operation.
+func writeOpenAPI3Operation(b *bytes.Buffer, ref openAPI3Op, rc *openAPI3RenderContext) {
+	method, path, anchor, pi, op := ref.Method, ref.Path, ref.Anchor, ref.PathItem, ref.Op
+	schemaLink, sharedLink, scopeDesc, errorsNote, linkTarget := rc.schemaLink, rc.sharedLink, rc.scopeDesc, rc.errorsNote, rc.linkTarget
+	headers, opts := rc.headers(ref), rc.opts
+	blankLine(b)
+	if opts.OperationMarkers {
+		writeOperationMarker(b, op.OperationID, method, path)
 	}
-	if op.Description != "" {
-		fmt.Fprintf(b, "%s\n\n", op.Description)
+	if explicitOperationAnchor(opts) {
+		fmt.Fprintf(b, "<a id=\"%s\"></a>\n", anchor)
+	}
+	fmt.Fprintf(b, "#### %s%s %s\n", methodBadge(method, opts), method, headingPath(path, func(name string) string { return pathParamTypeOpenAPI3(pi, op, name) }, opts))
+	if op.Deprecated {
+		writeDeprecationBadge(b, deprecationReason(op.Description, op.Extensions))
+	}
+	writeOperationIntro(b, op.Summary, op.Description, op.OperationID)
+
+	// Security (overrides the document default when present)
+	if op.Security != nil {
+		blankLine(b)
+		fmt.Fprintf(b, "**Security**\n- %s\n", formatSecurityRequirements(securityRequirementsOpenAPI3(*op.Security), scopeDesc))
 	}
 
+	// Servers (operation overrides win over path item overrides)
+	if opts.IncludeOperationServers {
+		servers := pi.Servers
+		if op.Servers != nil {
+			servers = *op.Servers
+		}
+		if len(servers) > 0 {
+			blankLine(b)
+			fmt.Fprintf(b, "**Servers**\n")
+			// An index that only fits the document's servers marks the first.
+			primary, err := primaryServer(servers, opts)
+			if err != nil {
+				primary = 0
+			}
+			for i, s := range servers {
+				writeOpenAPI3Server(b, s, i == primary && len(servers) > 1)
+			}
+		}
+	}
+
+	writeRequiredHeaders(b, headers)
+
 	// Parameters (PathItem + Operation)
 	params := append([]*openapi3.ParameterRef{}, pi.Parameters...)
 	params = append(params, op.Parameters...)
-	if len(params) > 0 {
+	var deprecated []*openapi3.ParameterRef
+	if opts.GroupDeprecatedParameters {
+		params, deprecated = splitDeprecated(params, func(pr *openapi3.ParameterRef) bool {
+			return pr != nil && pr.Value != nil && pr.Value.Deprecated
+		})
+	}
+	if opts.GroupParametersByLocation {
+		writeParameterGroups(b, params, func(pr *openapi3.ParameterRef) string {
+			if pr == nil || pr.Value == nil {
+				return ""
+			}
+			return pr.Value.In
+		}, func(pr *openapi3.ParameterRef) {
+			writeOpenAPI3Parameter(b, pr.Value, sharedLink(pr.Ref))
+		})
+	} else if len(params) > 0 {
+		blankLine(b)
 		fmt.Fprintf(b, "**Parameters**\n")
 		for _, pr := range params {
 			if pr == nil || pr.Value == nil {
 				continue
 			}
-			par := pr.Value
-			req := ""
-			if par.Required {
-				req = " (required)"
-			}
-			typ := "-"
-			if par.Schema != nil && par.Schema.Value != nil {
-				typ = typeOfSchemaRef(par.Schema)
-			}
-			desc := strings.TrimSpace(par.Description)
-			def := ""
-			if par.Schema != nil && par.Schema.Value != nil && par.Schema.Value.Default != nil {
-				def = fmt.Sprintf("%v", par.Schema.Value.Default)
-			}
-			line := fmt.Sprintf("- %s `%s` (%s)%s", par.In, par.Name, typ, req)
-			if desc != "" {
-				line += fmt.Sprintf(" — %s", desc)
-			}
-			if def != "" {
-				line += fmt.Sprintf(" [default: %s]", def)
-			}
-			fmt.Fprintln(b, line)
+			writeOpenAPI3Parameter(b, pr.Value, sharedLink(pr.Ref))
 		}
 	}
+	writeDeprecatedParameters(b, deprecated, func(pr *openapi3.ParameterRef) {
+		writeOpenAPI3Parameter(b, pr.Value, sharedLink(pr.Ref))
+	})
 
 	// Request Body
 	if op.RequestBody != nil && op.RequestBody.Value != nil && len(op.RequestBody.Value.Content) > 0 {
-		fmt.Fprintf(b, "\n**Request Body**\n")
-		// Stable order of media types
-		var mts []string
-		for mt := range op.RequestBody.Value.Content {
-			mts = append(mts, mt)
-		}
-		sort.Strings(mts)
-		for _, mt := range mts {
+		blankLine(b)
+		content := selectedContentOpenAPI3(op.RequestBody.Value.Content, opts)
+		differ := ""
+		if schemasDifferOpenAPI3(content) {
+			differ = " — " + schemasDifferNote
+		}
+		fmt.Fprintf(b, "**Request Body**%s%s\n", sharedLink(op.RequestBody.Ref), differ)
+		if len(content) == 0 {
+			fmt.Fprintf(b, "- %s\n", noMatchingMediaTypes)
+		}
+		for _, mt := range sortedKeys(content) {
 			media := op.RequestBody.Value.Content[mt]
 			typ := "-"
 			if media.Schema != nil && media.Schema.Value != nil {
 				typ = typeOfSchemaRef(media.Schema)
+				// Polymorphic bodies list their alternatives; a named one keeps
+				// its ref and adds them in parentheses.
+				if alt := alternativesOpenAPI3(media.Schema, schemaLink); alt != "" {
+					if media.Schema.Ref != "" {
+						typ = fmt.Sprintf("%s (%s)", typ, alt)
+					} else {
+						typ = alt
+					}
+				}
+			}
+			if exampleOnlyOpenAPI3(media) {
+				fmt.Fprintf(b, "- %s %s\n", mt, schemaNotSpecified)
+			} else if note := byteFormatNoteOpenAPI3(media.Schema, binaryUploadNote); note != "" {
+				fmt.Fprintf(b, "- %s — %s\n", mt, note)
+			} else {
+				recap := ""
+				if opts.InlineRequiredRecap {
+					recap = requiredRecapOpenAPI3(media.Schema)
+				}
+				fmt.Fprintf(b, "- %s — schema: %s%s\n", mt, typ, recap)
+			}
+			// Write-only properties are sent but never returned.
+			for _, pn := range writeOnlyPropertiesOpenAPI3(media.Schema) {
+				fmt.Fprintf(b, "  - `%s` (write-only)\n", pn)
+			}
+			// Discriminated oneOf: one entry per variant, each with its
+			// example. Named examples shown here are not repeated below.
+			shown := map[string]bool{}
+			if property, variants := discriminatedVariantsOpenAPI3(media.Schema); len(variants) > 0 {
+				fmt.Fprintf(b, "  - Discriminator: `%s`\n", property)
+				for _, v := range variants {
+					target := typeOfSchemaRef(v.Schema)
+					if name := refName(v.Schema.Ref); name != "" {
+						target = schemaLink(name)
+					}
+					fmt.Fprintf(b, "  - `%s` → %s\n", v.Value, target)
+				}
+				for _, v := range variants {
+					name, ex := variantExampleOpenAPI3(property, v, media.Examples)
+					if ex == nil {
+						continue
+					}
+					label := fmt.Sprintf("Request example (%s, %s)", v.Value, mt)
+					if name != "" {
+						shown[name] = true
+						label = fmt.Sprintf("Request example (%s: %s, %s)", v.Value, name, mt)
+					}
+					writeNamedExampleFence(b, label, ex.Summary, ex.Description, mt, ex.Value)
+				}
 			}
-			fmt.Fprintf(b, "- %s — schema: %s\n", mt, typ)
 			// Examples: inline example or named examples
 			if media.Example != nil {
 				writeExampleFence(b, "Request example ("+mt+")", mt, media.Example)
@@ -381,10 +753,13 @@ func writeOpenAPI3Operation(b *bytes.Buffer, method, path string, pi *openapi3.P
 					exNames = append(exNames, name)
 				}
 				sort.Strings(exNames)
+				compared := opts.ExampleComparisonTable &&
+					writeExampleComparison(b, "Request examples ("+mt+")", namedExamplesOpenAPI3(media.Examples, shown))
 				for _, name := range exNames {
 					exRef := media.Examples[name]
-					if exRef != nil && exRef.Value != nil && exRef.Value.Value != nil {
-						writeExampleFence(b, fmt.Sprintf("Request example (%s, %s)", name, mt), mt, exRef.Value.Value)
+					if exRef != nil && exRef.Value != nil && exRef.Value.Value != nil && !shown[name] && !compared {
+						ex := exRef.Value
+						writeNamedExampleFence(b, fmt.Sprintf("Request example (%s, %s)", name, mt), ex.Summary, ex.Description, mt, ex.Value)
 					}
 				}
 			}
@@ -395,14 +770,16 @@ func writeOpenAPI3Operation(b *bytes.Buffer, method, path string, pi *openapi3.P
 	if op.Responses != nil {
 		respMap := op.Responses.Map()
 		if len(respMap) > 0 {
-			fmt.Fprintf(b, "\n**Responses**\n")
+			blankLine(b)
+			fmt.Fprintf(b, "**Responses**\n")
 			var codes []string
 			for code := range respMap {
 				codes = append(codes, code)
 			}
-			sort.Strings(codes)
-			for _, code := range codes {
-				r := respMap[code]
+			sortResponseCodes(codes)
+			for _, key := range codes {
+				r := respMap[key]
+				code := strings.TrimSpace(key)
 				if r == nil || r.Value == nil {
 					continue
 				}
@@ -413,21 +790,39 @@ func writeOpenAPI3Operation(b *bytes.Buffer, method, path string, pi *openapi3.P
 				if desc == "" {
 					desc = "No description"
 				}
-				fmt.Fprintf(b, "- %s — %s\n", code, desc)
-				if len(r.Value.Content) > 0 {
-					// Stable order of media types
-					var mts []string
-					for mt := range r.Value.Content {
-						mts = append(mts, mt)
-					}
-					sort.Strings(mts)
-					for _, mt := range mts {
+				shared := ""
+				if r.Ref != "" {
+					shared = fmt.Sprintf(" (shared: %s)", refName(r.Ref))
+				}
+				fmt.Fprintf(b, "- %s%s — %s\n", responseLabel(code), shared, desc)
+				content := selectedContentOpenAPI3(r.Value.Content, opts)
+				if len(r.Value.Content) > 0 && len(content) == 0 {
+					fmt.Fprintf(b, "  - %s\n", noMatchingMediaTypes)
+				}
+				if len(content) > 0 {
+					for _, mt := range sortedKeys(content) {
 						media := r.Value.Content[mt]
 						typ := "-"
 						if media.Schema != nil && media.Schema.Value != nil {
 							typ = typeOfSchemaRef(media.Schema)
 						}
-						fmt.Fprintf(b, "  - %s — schema: %s\n", mt, typ)
+						dep := ""
+						if mediaTypeDeprecated(media.Extensions, opts) {
+							dep = " (deprecated)"
+						}
+						if note := bodyNoteOpenAPI3(mt, media.Schema); note != "" {
+							fmt.Fprintf(b, "  - %s%s — %s\n", mt, dep, note)
+						} else if exampleOnlyOpenAPI3(media) {
+							fmt.Fprintf(b, "  - %s%s %s\n", mt, dep, schemaNotSpecified)
+						} else if note := errorsNote(media.Schema); note != "" {
+							fmt.Fprintf(b, "  - %s%s — schema: %s%s\n", mt, dep, typ, note)
+						} else {
+							recap := ""
+							if opts.InlineRequiredRecap {
+								recap = requiredRecapOpenAPI3(media.Schema)
+							}
+							fmt.Fprintf(b, "  - %s%s — schema: %s%s\n", mt, dep, typ, recap)
+						}
 						// Examples per media type
 						if media.Example != nil {
 							writeExampleFence(b, fmt.Sprintf("Response example (%s, %s)", code, mt), mt, media.Example)
@@ -438,15 +833,223 @@ func writeOpenAPI3Operation(b *bytes.Buffer, method, path string, pi *openapi3.P
 								exNames = append(exNames, name)
 							}
 							sort.Strings(exNames)
+							compared := opts.ExampleComparisonTable &&
+								writeExampleComparison(b, fmt.Sprintf("Response examples (%s, %s)", code, mt), namedExamplesOpenAPI3(media.Examples, nil))
 							for _, name := range exNames {
 								exRef := media.Examples[name]
-								if exRef != nil && exRef.Value != nil && exRef.Value.Value != nil {
-									writeExampleFence(b, fmt.Sprintf("Response example (%s, %s, %s)", name, code, mt), mt, exRef.Value.Value)
+								if exRef != nil && exRef.Value != nil && exRef.Value.Value != nil && !compared {
+									ex := exRef.Value
+									writeNamedExampleFence(b, fmt.Sprintf("Response example (%s, %s, %s)", name, code, mt), ex.Summary, ex.Description, mt, ex.Value)
 								}
 							}
 						}
 					}
 				}
+				writeLinksOpenAPI3(b, r.Value.Links, linkTarget)
+			}
+		}
+	}
+
+	writeCodeSamples(b, op.Extensions, opts.CodeSamplesKey)
+}
+
+// writeOAuthFlowsOpenAPI3 writes each OAuth2 flow of a security scheme as a
+// nested list of its URLs and scopes, in the order the specification lists
+// the flows.
+func writeOAuthFlowsOpenAPI3(b *bytes.Buffer, flows *openapi3.OAuthFlows) {
+	if flows == nil {
+		return
+	}
+	for _, f := range []struct {
+		name string
+		flow *openapi3.OAuthFlow
+	}{
+		{"implicit", flows.Implicit},
+		{"password", flows.Password},
+		{"clientCredentials", flows.ClientCredentials},
+		{"authorizationCode", flows.AuthorizationCode},
+	} {
+		if f.flow == nil {
+			continue
+		}
+		fmt.Fprintf(b, "  - %s flow\n", f.name)
+		if f.flow.AuthorizationURL != "" {
+			fmt.Fprintf(b, "    - Authorization URL: %s\n", f.flow.AuthorizationURL)
+		}
+		if f.flow.TokenURL != "" {
+			fmt.Fprintf(b, "    - Token URL: %s\n", f.flow.TokenURL)
+		}
+		if f.flow.RefreshURL != "" {
+			fmt.Fprintf(b, "    - Refresh URL: %s\n", f.flow.RefreshURL)
+		}
+		if len(f.flow.Scopes) > 0 {
+			b.WriteString("    - Scopes:\n")
+			for _, scope := range sortedKeys(f.flow.Scopes) {
+				if desc := strings.TrimSpace(f.flow.Scopes[scope]); desc != "" {
+					fmt.Fprintf(b, "      - `%s` — %s\n", scope, desc)
+				} else {
+					fmt.Fprintf(b, "      - `%s`\n", scope)
+				}
+			}
+		}
+	}
+}
+
+// writeOpenAPI3Server writes one server list item, followed by an example
+// URL with its variables filled in when it has any.
+func writeOpenAPI3Server(b *bytes.Buffer, s *openapi3.Server, primary bool) {
+	u := s.URL
+	if len(s.Variables) > 0 {
+		u += " {vars}"
+	}
+	fmt.Fprintf(b, "- %s", u)
+	if d := strings.TrimSpace(s.Description); d != "" {
+		fmt.Fprintf(b, " — %s", d)
+	}
+	if primary {
+		b.WriteString(" (primary)")
+	}
+	b.WriteByte('\n')
+	if len(s.Variables) > 0 {
+		resolved, unresolved := resolveServerURL(s)
+		fmt.Fprintf(b, "  - Example: `%s`\n", resolved)
+		for _, name := range unresolved {
+			fmt.Fprintf(b, "  - Note: `{%s}` has no default or enum value and is left as a placeholder\n", name)
+		}
+	}
+}
+
+// namedExamplesOpenAPI3 lists the named examples with a value, in name
+// order, leaving out those in skip.
+func namedExamplesOpenAPI3(examples openapi3.Examples, skip map[string]bool) []namedExample {
+	var list []namedExample
+	for _, name := range sortedKeys(examples) {
+		exRef := examples[name]
+		if exRef == nil || exRef.Value == nil || exRef.Value.Value == nil || skip[name] {
+			continue
+		}
+		list = append(list, namedExample{Name: name, Summary: exRef.Value.Summary, Value: exRef.Value.Value})
+	}
+	return list
+}
+
+// selectedContentOpenAPI3 returns the media types of content that pass
+// opts.MediaTypeFilter.
+func selectedContentOpenAPI3(content openapi3.Content, opts Options) openapi3.Content {
+	if opts.MediaTypeFilter == nil {
+		return content
+	}
+	selected := openapi3.Content{}
+	for _, mt := range selectedMediaTypes(sortedKeys(content), opts) {
+		selected[mt] = content[mt]
+	}
+	return selected
+}
+
+// pathParamTypeOpenAPI3 returns the schema type of the path parameter called
+// name, an operation parameter taking precedence over a path-level one, or
+// "" when there is no such parameter or it has no type.
+func pathParamTypeOpenAPI3(pi *openapi3.PathItem, op *openapi3.Operation, name string) string {
+	for _, params := range []openapi3.Parameters{op.Parameters, pi.Parameters} {
+		for _, pr := range params {
+			if pr == nil || pr.Value == nil || pr.Value.In != "path" || pr.Value.Name != name {
+				continue
+			}
+			if pr.Value.Schema != nil && pr.Value.Schema.Value != nil {
+				if types := pr.Value.Schema.Value.Type.Slice(); len(types) > 0 {
+					return types[0]
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// writeOpenAPI3Parameter writes one parameter list item; shared is appended
+// after the markers when the parameter is a reusable component.
+func writeOpenAPI3Parameter(b *bytes.Buffer, par *openapi3.Parameter, shared string) {
+	req := ""
+	if par.Required {
+		req = " (required)"
+	}
+	typ := "-"
+	if par.Schema != nil && par.Schema.Value != nil {
+		typ = typeOfSchemaRef(par.Schema)
+	}
+	desc := strings.TrimSpace(par.Description)
+	def := ""
+	if par.Schema != nil && par.Schema.Value != nil && par.Schema.Value.Default != nil {
+		def = fmt.Sprintf("%v", par.Schema.Value.Default)
+	}
+	if par.AllowEmptyValue {
+		req += " (allows empty)"
+	}
+	if par.Deprecated {
+		req += " (deprecated)"
+	}
+	fmt.Fprintf(b, "- %s `%s` (%s)%s%s", par.In, par.Name, typ, req, shared)
+	if desc != "" {
+		fmt.Fprintf(b, " — %s", desc)
+	}
+	if def != "" {
+		fmt.Fprintf(b, " [default: %s]", literal(def))
+	}
+	if par.Schema != nil && par.Schema.Value != nil {
+		sv := par.Schema.Value
+		if bounds := numericBounds(sv.Min, sv.Max, sv.ExclusiveMin, sv.ExclusiveMax); bounds != "" {
+			fmt.Fprintf(b, " %s", bounds)
+		}
+		if constraints := constraintsOpenAPI3(sv); constraints != "" {
+			fmt.Fprintf(b, " %s", constraints)
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// writeOpenAPI3ReusableSections writes the opt-in reference sections for
+// components.parameters and components.requestBodies.
+func writeOpenAPI3ReusableSections(b *bytes.Buffer, components *openapi3.Components, schemaLink func(string) string, opts Options) {
+	if opts.ReusableParameters && len(components.Parameters) > 0 {
+		fmt.Fprintf(b, "\n## Reusable Parameters\n")
+		for _, name := range sortedKeys(components.Parameters) {
+			pr := components.Parameters[name]
+			if pr == nil || pr.Value == nil {
+				continue
+			}
+			writeSubheading(b, name, opts)
+			writeOpenAPI3Parameter(b, pr.Value, "")
+		}
+	}
+
+	if opts.ReusableRequestBodies && len(components.RequestBodies) > 0 {
+		fmt.Fprintf(b, "\n## Reusable Request Bodies\n")
+		for _, name := range sortedKeys(components.RequestBodies) {
+			rb := components.RequestBodies[name]
+			if rb == nil || rb.Value == nil {
+				continue
+			}
+			writeSubheading(b, name, opts)
+			if d := strings.TrimSpace(rb.Value.Description); d != "" {
+				fmt.Fprintf(b, "%s\n\n", d)
+			}
+			if rb.Value.Required {
+				fmt.Fprintf(b, "Required.\n\n")
+			}
+			for _, mt := range sortedKeys(rb.Value.Content) {
+				media := rb.Value.Content[mt]
+				typ := "-"
+				if media.Schema != nil && media.Schema.Value != nil {
+					typ = typeOfSchemaRef(media.Schema)
+					if alt := alternativesOpenAPI3(media.Schema, schemaLink); alt != "" {
+						if media.Schema.Ref != "" {
+							typ = fmt.Sprintf("%s (%s)", typ, alt)
+						} else {
+							typ = alt
+						}
+					}
+				}
+				fmt.Fprintf(b, "- %s — schema: %s\n", mt, typ)
 			}
 		}
 	}
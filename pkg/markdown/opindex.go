@@ -0,0 +1,228 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// The endpoint and example passes walk the same operations in the same
+// order. The index is built once per render, sorted by path and then by the
+// fixed method order below, reordered by Options.SortOperationsBy, with
+// Options.Methods and Options.ExcludeInternal already applied.
+
+// methodOrder is the order operations of one path are listed in.
+var methodOrder = map[string]int{
+	"GET": 0, "POST": 1, "PUT": 2, "DELETE": 3, "PATCH": 4, "OPTIONS": 5, "HEAD": 6, "TRACE": 7,
+}
+
+// checkOperationSort reports an error for an unknown Options.SortOperationsBy.
+func checkOperationSort(by OperationSort) error {
+	switch by {
+	case SortByPath, SortByMethod, SortByOperationID, "":
+		return nil
+	}
+	return fmt.Errorf("unknown operation sort %q, must be one of: path,method,operationId", by)
+}
+
+// sortOperations reorders an index built in path order. The sort is stable,
+// so path order breaks ties.
+func sortOperations[T any](index []T, by OperationSort, key func(T) (method, operationID string)) {
+	switch by {
+	case SortByMethod:
+		sort.SliceStable(index, func(i, j int) bool {
+			mi, _ := key(index[i])
+			mj, _ := key(index[j])
+			return methodOrder[mi] < methodOrder[mj]
+		})
+	case SortByOperationID:
+		sort.SliceStable(index, func(i, j int) bool {
+			_, idi := key(index[i])
+			_, idj := key(index[j])
+			if idi == "" || idj == "" {
+				return idi != "" && idj == ""
+			}
+			return idi < idj
+		})
+	}
+}
+
+// openAPI3Op is one operation in an OpenAPI 3 operation index.
+type openAPI3Op struct {
+	Method   string
+	Path     string
+	PathItem *openapi3.PathItem
+	Op       *openapi3.Operation
+	// Anchor is the operation heading's anchor, without "#", once set by
+	// the renderer from operationAnchors.
+	Anchor string
+}
+
+// indexOpenAPI3Operations lists the operations of doc that opts allows.
+func indexOpenAPI3Operations(doc *openapi3.T, opts Options) []openAPI3Op {
+	if doc.Paths == nil {
+		return nil
+	}
+	pathMap := doc.Paths.Map()
+	pathKeys := sortedKeys(pathMap)
+
+	index := make([]openAPI3Op, 0, len(pathKeys))
+	for _, p := range pathKeys {
+		pi := pathMap[p]
+		ops := [...]struct {
+			method string
+			op     *openapi3.Operation
+		}{
+			{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put}, {"DELETE", pi.Delete},
+			{"PATCH", pi.Patch}, {"OPTIONS", pi.Options}, {"HEAD", pi.Head}, {"TRACE", pi.Trace},
+		}
+		for _, it := range ops {
+			if it.op == nil || !opts.methodAllowed(it.method) || opts.internal(pi.Extensions, it.op.Extensions) {
+				continue
+			}
+			index = append(index, openAPI3Op{Method: it.method, Path: p, PathItem: pi, Op: it.op})
+		}
+	}
+	sortOperations(index, opts.SortOperationsBy, func(o openAPI3Op) (string, string) { return o.Method, o.Op.OperationID })
+	return index
+}
+
+// swagger2Op is one operation in a Swagger 2.0 operation index.
+type swagger2Op struct {
+	Method   string
+	Path     string
+	PathItem *spec.PathItem
+	Op       *spec.Operation
+	// Anchor is as for openAPI3Op.
+	Anchor string
+}
+
+// indexSwagger2Operations lists the operations of s that opts allows.
+func indexSwagger2Operations(s *spec.Swagger, opts Options) []swagger2Op {
+	if s.Paths == nil {
+		return nil
+	}
+	paths := sortedKeys(s.Paths.Paths)
+
+	index := make([]swagger2Op, 0, len(paths))
+	for _, p := range paths {
+		pi := s.Paths.Paths[p]
+		ops := [...]struct {
+			method string
+			op     *spec.Operation
+		}{
+			{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put}, {"DELETE", pi.Delete},
+			{"PATCH", pi.Patch}, {"OPTIONS", pi.Options}, {"HEAD", pi.Head},
+		}
+		for _, it := range ops {
+			if it.op == nil || !opts.methodAllowed(it.method) || opts.internal(pi.Extensions, it.op.Extensions) {
+				continue
+			}
+			index = append(index, swagger2Op{Method: it.method, Path: p, PathItem: &pi, Op: it.op})
+		}
+	}
+	sortOperations(index, opts.SortOperationsBy, func(o swagger2Op) (string, string) { return o.Method, o.Op.ID })
+	return index
+}
+
+// operationAnchors returns the heading anchor of each operation in index, in
+// order: the slug of "METHOD /path", or with Options.PreferOperationIdAnchors
+// the slug of its operationId when it has one, so deep links survive path
+// renames; the option also makes anchors unique by appending "-1", "-2",
+// ... to repeats, as GitHub does for repeated headings.
+func operationAnchors[T any](index []T, key func(T) (method, path, operationID string), slug func(string) string, opts Options) []string {
+	anchors := make([]string, len(index))
+	used := map[string]bool{}
+	for i, o := range index {
+		method, path, id := key(o)
+		anchors[i] = slug(method + " " + path)
+		if !opts.PreferOperationIdAnchors {
+			continue
+		}
+		if id != "" {
+			anchors[i] = slug(id)
+		}
+		base := anchors[i]
+		for n := 1; used[anchors[i]]; n++ {
+			anchors[i] = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[anchors[i]] = true
+	}
+	return anchors
+}
+
+// explicitOperationAnchor reports whether operation headings need an
+// explicit anchor because the renderer's own would not match
+// operationAnchors.
+func explicitOperationAnchor(opts Options) bool {
+	return opts.AnchorPrefix != "" || opts.PreferOperationIdAnchors || opts.MethodBadges || opts.AnnotatePathParams
+}
+
+// defaultMethodBadges are the MethodBadges badges, by method.
+var defaultMethodBadges = map[string]string{
+	"GET":     "🟢",
+	"POST":    "🟡",
+	"PUT":     "🔵",
+	"PATCH":   "🟣",
+	"DELETE":  "🔴",
+	"HEAD":    "⚪",
+	"OPTIONS": "⚪",
+	"TRACE":   "⚪",
+}
+
+// methodBadge returns the badge and space that prefix an operation heading's
+// method under opts.MethodBadges, or "".
+func methodBadge(method string, opts Options) string {
+	if !opts.MethodBadges {
+		return ""
+	}
+	badge, ok := opts.MethodBadgeMap[method]
+	if !ok {
+		badge = defaultMethodBadges[method]
+	}
+	if badge == "" {
+		return ""
+	}
+	return badge + " "
+}
+
+// pathPlaceholder matches a path template placeholder such as "{petId}".
+var pathPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// headingPath is the path as written in an operation heading: under
+// opts.AnnotatePathParams each placeholder carries its parameter's type from
+// paramType, which returns "" when it is unknown.
+func headingPath(path string, paramType func(name string) string, opts Options) string {
+	if !opts.AnnotatePathParams {
+		return path
+	}
+	return pathPlaceholder.ReplaceAllStringFunc(path, func(m string) string {
+		name := m[1 : len(m)-1]
+		if typ := paramType(name); typ != "" {
+			return fmt.Sprintf("{%s: %s}", name, typ)
+		}
+		return m
+	})
+}
+
+// groupByTag groups index by operation tag, as the Endpoints by Tag section
+// lists it: names holds the tags in sorted order, tagged[name] the
+// operations carrying that tag in index order (an operation with several
+// tags appears under each), and untagged the operations without tags.
+func groupByTag[T any](index []T, tags func(T) []string) (names []string, tagged map[string][]T, untagged []T) {
+	tagged = map[string][]T{}
+	for _, o := range index {
+		ts := tags(o)
+		if len(ts) == 0 {
+			untagged = append(untagged, o)
+			continue
+		}
+		for _, tag := range ts {
+			tagged[tag] = append(tagged[tag], o)
+		}
+	}
+	return sortedKeys(tagged), tagged, untagged
+}
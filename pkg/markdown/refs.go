@@ -0,0 +1,175 @@
+package markdown
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// Schema reference closure: which named schemas an operation touches, directly
+// or through other schemas.
+
+const (
+	openAPI3SchemaRefPrefix = "#/components/schemas/"
+	swagger2SchemaRefPrefix = "#/definitions/"
+)
+
+// refSet collects named schema references.
+type refSet map[string]struct{}
+
+// sorted returns the collected names in sorted order.
+func (rs refSet) sorted() []string {
+	names := make([]string, 0, len(rs))
+	for name := range rs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addSchemaRefOpenAPI3 records every component schema reachable from ref.
+// kin-openapi resolves $refs in place, so a referenced schema's Value is the
+// component itself; recursion stops at names already in the set, which also
+// guards against cyclic models.
+func (rs refSet) addSchemaRefOpenAPI3(ref *openapi3.SchemaRef) {
+	if ref == nil {
+		return
+	}
+	if strings.HasPrefix(ref.Ref, openAPI3SchemaRefPrefix) {
+		name := strings.TrimPrefix(ref.Ref, openAPI3SchemaRefPrefix)
+		if _, seen := rs[name]; seen {
+			return
+		}
+		rs[name] = struct{}{}
+	}
+	s := ref.Value
+	if s == nil {
+		return
+	}
+	for _, pn := range sortedKeys(s.Properties) {
+		rs.addSchemaRefOpenAPI3(s.Properties[pn])
+	}
+	rs.addSchemaRefOpenAPI3(s.Items)
+	rs.addSchemaRefOpenAPI3(s.AdditionalProperties.Schema)
+	rs.addSchemaRefOpenAPI3(s.Not)
+	for _, group := range []openapi3.SchemaRefs{s.AllOf, s.OneOf, s.AnyOf} {
+		for _, member := range group {
+			rs.addSchemaRefOpenAPI3(member)
+		}
+	}
+}
+
+// addOperationOpenAPI3 records the schemas used by an operation's parameters
+// (including path-level ones), request body, and responses.
+func (rs refSet) addOperationOpenAPI3(pi *openapi3.PathItem, op *openapi3.Operation) {
+	params := append([]*openapi3.ParameterRef{}, pi.Parameters...)
+	params = append(params, op.Parameters...)
+	for _, pr := range params {
+		if pr != nil && pr.Value != nil {
+			rs.addSchemaRefOpenAPI3(pr.Value.Schema)
+		}
+	}
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		for _, media := range op.RequestBody.Value.Content {
+			rs.addSchemaRefOpenAPI3(media.Schema)
+		}
+	}
+	if op.Responses != nil {
+		for _, r := range op.Responses.Map() {
+			if r == nil || r.Value == nil {
+				continue
+			}
+			for _, media := range r.Value.Content {
+				rs.addSchemaRefOpenAPI3(media.Schema)
+			}
+			for _, h := range r.Value.Headers {
+				if h != nil && h.Value != nil {
+					rs.addSchemaRefOpenAPI3(h.Value.Schema)
+				}
+			}
+		}
+	}
+}
+
+// addSchemaSwagger2 records every definition reachable from s. go-openapi does
+// not resolve $refs, so referenced definitions are looked up in defs by name.
+func (rs refSet) addSchemaSwagger2(s *spec.Schema, defs spec.Definitions) {
+	if s == nil {
+		return
+	}
+	if ref := s.Ref.String(); strings.HasPrefix(ref, swagger2SchemaRefPrefix) {
+		name := strings.TrimPrefix(ref, swagger2SchemaRefPrefix)
+		if _, seen := rs[name]; seen {
+			return
+		}
+		rs[name] = struct{}{}
+		if def, ok := defs[name]; ok {
+			rs.addSchemaSwagger2(&def, defs)
+		}
+		return
+	}
+	for _, pn := range sortedKeys(s.Properties) {
+		ps := s.Properties[pn]
+		rs.addSchemaSwagger2(&ps, defs)
+	}
+	if s.Items != nil {
+		rs.addSchemaSwagger2(s.Items.Schema, defs)
+		for i := range s.Items.Schemas {
+			rs.addSchemaSwagger2(&s.Items.Schemas[i], defs)
+		}
+	}
+	if s.AdditionalProperties != nil {
+		rs.addSchemaSwagger2(s.AdditionalProperties.Schema, defs)
+	}
+	rs.addSchemaSwagger2(s.Not, defs)
+	for _, group := range [][]spec.Schema{s.AllOf, s.OneOf, s.AnyOf} {
+		for i := range group {
+			rs.addSchemaSwagger2(&group[i], defs)
+		}
+	}
+}
+
+// addOperationSwagger2 records the definitions used by an operation's body
+// parameter (including a path-level one) and responses.
+func (rs refSet) addOperationSwagger2(pi *spec.PathItem, op *spec.Operation, defs spec.Definitions) {
+	for _, prm := range mergedParametersSwagger2(pi, op) {
+		rs.addSchemaSwagger2(prm.Schema, defs)
+	}
+	if op.Responses == nil {
+		return
+	}
+	for _, r := range op.Responses.StatusCodeResponses {
+		rs.addSchemaSwagger2(r.Schema, defs)
+	}
+	if op.Responses.Default != nil {
+		rs.addSchemaSwagger2(op.Responses.Default.Schema, defs)
+	}
+}
+
+// schemaUsers inverts the per-operation reference closure for
+// Options.SchemaUsageBackrefs: it maps each named schema to the operations
+// that use it, in operation order. collect adds an operation's schemas to a
+// refSet and ref describes the operation.
+func schemaUsers[T any](operations []T, collect func(T, refSet), ref func(T) operationReference) map[string][]operationReference {
+	users := map[string][]operationReference{}
+	for _, op := range operations {
+		rs := refSet{}
+		collect(op, rs)
+		for name := range rs {
+			users[name] = append(users[name], ref(op))
+		}
+	}
+	return users
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
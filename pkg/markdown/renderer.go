@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/spec"
+)
+
+// OutputFormat selects the renderer used for the parsed document. The zero
+// value behaves like OutputMarkdown.
+type OutputFormat string
+
+const (
+	// OutputMarkdown renders GitHub-flavored Markdown.
+	OutputMarkdown OutputFormat = "markdown"
+	// OutputJSONL renders a JSON Lines search index: one object per
+	// operation with its method, path, operationId, tags, summary,
+	// description, and Markdown heading anchor.
+	OutputJSONL OutputFormat = "jsonl"
+)
+
+// formatExtensions is the file extension for each built-in OutputFormat.
+var formatExtensions = map[OutputFormat]string{
+	OutputMarkdown: ".md",
+	OutputJSONL:    ".jsonl",
+}
+
+// Extension returns the file extension written for f, such as ".md", or ""
+// when f is not a built-in format. The zero value is OutputMarkdown.
+func (f OutputFormat) Extension() string {
+	if f == "" {
+		f = OutputMarkdown
+	}
+	return formatExtensions[f]
+}
+
+// OutputFiles returns the intended output of a ConvertAll result written to
+// out, keyed by path: out itself for a single format, otherwise out with its
+// extension replaced by each format's, so "docs/api.md" becomes
+// "docs/api.md" and "docs/api.jsonl". An empty out with a single format
+// stands for standard output. ConvertDir returns the same kind of map for a
+// directory of specs.
+func OutputFiles(out string, rendered map[OutputFormat]string) map[string]string {
+	files := make(map[string]string, len(rendered))
+	for f, content := range rendered {
+		path := out
+		if len(rendered) > 1 {
+			path = strings.TrimSuffix(out, filepath.Ext(out)) + f.Extension()
+		}
+		files[path] = content
+	}
+	return files
+}
+
+// Renderer turns a parsed document into output text. raw is the JSON the
+// document was decoded from, or nil for documents built in code; renderers
+// use it for details the parsed models drop (such as non-numeric Swagger 2.0
+// response codes) and may ignore it. OpenAPI 3 documents have already been
+// validated as Options.SkipValidation and Options.StrictValidation ask.
+type Renderer interface {
+	RenderOpenAPI3(doc *openapi3.T, raw []byte, opts Options) (string, error)
+	RenderSwagger2(doc *spec.Swagger, raw []byte, opts Options) (string, error)
+}
+
+// markdownRenderer is the built-in Markdown Renderer.
+type markdownRenderer struct{}
+
+func (markdownRenderer) RenderOpenAPI3(doc *openapi3.T, raw []byte, opts Options) (string, error) {
+	return renderOpenAPI3(doc, raw, opts)
+}
+
+func (markdownRenderer) RenderSwagger2(doc *spec.Swagger, raw []byte, opts Options) (string, error) {
+	return renderSwagger2(doc, raw, opts)
+}
+
+// renderers maps each built-in OutputFormat to its Renderer.
+var renderers = map[OutputFormat]Renderer{
+	OutputMarkdown: markdownRenderer{},
+	OutputJSONL:    jsonlRenderer{},
+}
+
+// selectRenderer returns opts.Renderer when set, otherwise the built-in
+// renderer for opts.OutputFormat.
+func selectRenderer(opts Options) (Renderer, error) {
+	if opts.Renderer != nil {
+		return opts.Renderer, nil
+	}
+	format := opts.OutputFormat
+	if format == "" {
+		format = OutputMarkdown
+	}
+	r, ok := renderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return r, nil
+}
@@ -0,0 +1,141 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxRootRefDepth bounds chains of indirection files, which also stops
+// documents that refer back to themselves.
+const maxRootRefDepth = 8
+
+// resolveRootRef follows a whole-document "$ref" such as
+// {"$ref": "actual-spec.yaml"}, returning the normalized JSON of the document
+// it points to and that document's location: its file path when reading from
+// Options.BaseDir, or its resolved ref when using Options.RefFetcher. Input
+// without a root "$ref" is returned unchanged with an empty location. In a
+// chain of indirection files, each relative ref resolves against the file
+// that holds it: against its directory when reading from Options.BaseDir, and
+// against the referring ref when passed to Options.RefFetcher.
+func resolveRootRef(jsonData []byte, opts Options) ([]byte, string, error) {
+	baseDir, baseRef, location := opts.BaseDir, "", ""
+	for depth := 0; ; depth++ {
+		ref, ok := rootRef(jsonData)
+		if !ok {
+			return jsonData, location, nil
+		}
+		if depth == maxRootRefDepth {
+			return nil, "", fmt.Errorf("root $ref %q: more than %d levels of indirection", ref, maxRootRefDepth)
+		}
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			return nil, "", fmt.Errorf("root $ref %q does not name a document", ref)
+		}
+
+		var data []byte
+		var err error
+		switch {
+		case opts.RefFetcher != nil:
+			if baseRef, err = resolveRef(baseRef, ref); err != nil {
+				return nil, "", fmt.Errorf("resolve root $ref %q: %w", ref, err)
+			}
+			location = baseRef
+			data, err = opts.RefFetcher(baseRef)
+		case baseDir != "":
+			path := ref
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			baseDir, location = filepath.Dir(path), path
+			data, err = os.ReadFile(path)
+		default:
+			return nil, "", fmt.Errorf("root $ref %q cannot be resolved: set Options.BaseDir or Options.RefFetcher", ref)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("resolve root $ref %q: %w", ref, err)
+		}
+
+		// The target's format is independent of the indirection file's.
+		jsonData, err = normalizeToJSON(data, FormatAuto, 0)
+		if err != nil {
+			return nil, "", fmt.Errorf("resolve root $ref %q: %w", ref, err)
+		}
+	}
+}
+
+// resolveRef resolves ref against base, the ref of the file that holds it:
+// "specs/a.yaml" then "b.yaml" gives "specs/b.yaml", and URL bases resolve as
+// URL references. An empty base, or an absolute ref, leaves ref as written.
+func resolveRef(base, ref string) (string, error) {
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	if base == "" || r.IsAbs() || path.IsAbs(ref) || filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if b.IsAbs() {
+		return b.ResolveReference(r).String(), nil
+	}
+	return path.Join(path.Dir(base), ref), nil
+}
+
+// documentLocation is the URL an OpenAPI 3 document's relative external refs
+// resolve against: the location resolveRootRef reported for it, or, without
+// a root "$ref", Options.BaseDir itself.
+func documentLocation(opts Options) (*url.URL, error) {
+	if opts.RefFetcher != nil {
+		return url.Parse(opts.location)
+	}
+	if opts.location != "" {
+		return &url.URL{Path: filepath.ToSlash(opts.location)}, nil
+	}
+	// The trailing slash makes the loader join refs to BaseDir, not its parent.
+	return &url.URL{Path: filepath.ToSlash(opts.BaseDir) + "/"}, nil
+}
+
+// readExternalRef reads the target of an external ref through
+// Options.RefFetcher when set, and otherwise from the file system only:
+// BaseDir never reaches the network.
+func readExternalRef(opts Options) openapi3.ReadFromURIFunc {
+	if opts.RefFetcher == nil {
+		return openapi3.ReadFromFile
+	}
+	return func(_ *openapi3.Loader, location *url.URL) ([]byte, error) {
+		ref := *location
+		ref.Fragment = ""
+		return opts.RefFetcher(ref.String())
+	}
+}
+
+// rootRef reports the "$ref" of a document whose only top-level key is
+// "$ref". Only the leading tokens are read, so a full spec is turned away
+// after its first key rather than decoded.
+func rootRef(jsonData []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return "", false
+	}
+	if key, err := dec.Token(); err != nil || key != "$ref" {
+		return "", false
+	}
+	var ref string
+	if err := dec.Decode(&ref); err != nil {
+		return "", false
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('}') {
+		return "", false
+	}
+	return ref, true
+}
@@ -0,0 +1,116 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// anchorFunc returns the anchor of a heading under opts: its SlugStyle slug
+// with AnchorPrefix prepended. Cross-links use it to reach headings.
+func anchorFunc(opts Options) (func(string) string, error) {
+	slug, err := slugFunc(opts.SlugStyle)
+	if err != nil || opts.AnchorPrefix == "" {
+		return slug, err
+	}
+	return func(heading string) string { return opts.AnchorPrefix + slug(heading) }, nil
+}
+
+// writeAnchor emits an explicit anchor for the heading about to be written,
+// when opts.AnchorPrefix is set. Without a prefix the renderer's own
+// heading anchor already matches anchorFunc.
+func writeAnchor(b *bytes.Buffer, heading string, opts Options) {
+	if opts.AnchorPrefix == "" {
+		return
+	}
+	writeExplicitAnchor(b, heading, opts)
+}
+
+// writeExplicitAnchor emits the <a id> anchor of heading unconditionally.
+func writeExplicitAnchor(b *bytes.Buffer, heading string, opts Options) {
+	if anchor, err := anchorFunc(opts); err == nil {
+		fmt.Fprintf(b, "<a id=\"%s\"></a>\n", anchor(heading))
+	}
+}
+
+// writeSubheading emits a "### " heading preceded by a blank line and its
+// explicit anchor, if any.
+func writeSubheading(b *bytes.Buffer, heading string, opts Options) {
+	b.WriteByte('\n')
+	writeAnchor(b, heading, opts)
+	fmt.Fprintf(b, "### %s\n", heading)
+}
+
+// writeSchemaHeading is writeSubheading for a schema, whose anchor is
+// explicit whenever opts.EmitAnchorsForSchemas is set.
+func writeSchemaHeading(b *bytes.Buffer, name string, opts Options) {
+	if !opts.EmitAnchorsForSchemas || opts.AnchorPrefix != "" {
+		writeSubheading(b, name, opts)
+		return
+	}
+	b.WriteByte('\n')
+	writeExplicitAnchor(b, name, opts)
+	fmt.Fprintf(b, "### %s\n", name)
+}
+
+// slugFunc returns the heading-to-anchor function for style.
+func slugFunc(style SlugStyle) (func(string) string, error) {
+	switch style {
+	case SlugGitHub, "":
+		return githubSlug, nil
+	case SlugGitLab:
+		return gitlabSlug, nil
+	case SlugMkDocs:
+		return mkdocsSlug, nil
+	default:
+		return nil, fmt.Errorf("unknown slug style %q, must be one of: github,gitlab,mkdocs", style)
+	}
+}
+
+// githubSlug turns a heading into the anchor GitHub generates for it:
+// lowercased, punctuation dropped, and spaces replaced by hyphens.
+func githubSlug(heading string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case r == ' ':
+			sb.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// gitlabSlug is githubSlug with runs of hyphens collapsed, as GitLab does.
+func gitlabSlug(heading string) string {
+	return collapseHyphens(githubSlug(heading))
+}
+
+// mkdocsSlug mirrors Python-Markdown's default toc slugify: non-ASCII and
+// punctuation other than '-' and '_' are dropped, the rest is trimmed and
+// lowercased, and runs of whitespace and hyphens become a single hyphen.
+func mkdocsSlug(heading string) string {
+	var kept strings.Builder
+	for _, r := range heading {
+		if r <= unicode.MaxASCII && (r == '-' || r == '_' || unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			kept.WriteRune(r)
+		}
+	}
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(kept.String())) {
+		if unicode.IsSpace(r) {
+			r = '-'
+		}
+		sb.WriteRune(r)
+	}
+	return collapseHyphens(sb.String())
+}
+
+func collapseHyphens(s string) string {
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package markdown
+
+// Summary holds counts of a spec's top-level pieces, for scripts that
+// assert a spec has grown or shrunk.
+type Summary struct {
+	Paths           int
+	Operations      int
+	Schemas         int
+	Tags            int
+	SecuritySchemes int
+}
+
+// Summarize parses data like ToMarkdown and counts its paths, operations,
+// schemas (definitions in Swagger 2.0), declared tags, and security schemes.
+// Nothing is validated or rendered. Operations honor Options.Methods.
+func Summarize(data []byte, opts Options) (Summary, error) {
+	jsonData, opts, err := normalizeInput(data, opts)
+	if err != nil {
+		return Summary{}, err
+	}
+	parsed, err := parseJSON(jsonData, opts)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var sum Summary
+	if doc := parsed.openAPI3; doc != nil {
+		if doc.Paths != nil {
+			sum.Paths = doc.Paths.Len()
+		}
+		sum.Operations = len(indexOpenAPI3Operations(doc, opts))
+		sum.Tags = len(doc.Tags)
+		if doc.Components != nil {
+			sum.Schemas = len(doc.Components.Schemas)
+			sum.SecuritySchemes = len(doc.Components.SecuritySchemes)
+		}
+		return sum, nil
+	}
+	s := parsed.swagger2
+	if s.Paths != nil {
+		sum.Paths = len(s.Paths.Paths)
+	}
+	sum.Operations = len(indexSwagger2Operations(s, opts))
+	sum.Schemas = len(s.Definitions)
+	sum.Tags = len(s.Tags)
+	sum.SecuritySchemes = len(s.SecurityDefinitions)
+	return sum, nil
+}
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-openapi/spec"
@@ -12,20 +15,146 @@ import (
 
 // Swagger 2.0 (OpenAPI 2.0) markdown generation.
 
-func swagger2ToMarkdown(data []byte) (md string, err error) {
+// parseSwagger2 decodes a Swagger 2.0 document from normalized JSON.
+func parseSwagger2(data []byte, opts Options) (s *spec.Swagger, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("swagger2 conversion panic: %v", r)
+			err = conversionPanic("swagger2", PhaseParse, r, opts)
+			s = nil
+		}
+	}()
+
+	done := phaseTimer(opts, PhaseParse)
+	s = &spec.Swagger{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse swagger 2.0: %w", err)
+	}
+	done()
+	return s, nil
+}
+
+// renderSwagger2 renders a decoded Swagger 2.0 document. raw is the JSON the
+// document was decoded from, or nil for documents built in code.
+func renderSwagger2(s *spec.Swagger, raw []byte, opts Options) (md string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = conversionPanic("swagger2", PhaseRender, r, opts)
 			md = ""
 		}
 	}()
 
-	var s spec.Swagger
-	if err := json.Unmarshal(data, &s); err != nil {
-		return "", fmt.Errorf("parse swagger 2.0: %w", err)
+	if s == nil {
+		return "", fmt.Errorf("render swagger 2.0: nil document")
+	}
+	defer phaseTimer(opts, PhaseRender)()
+
+	s, unknown := recoverSwagger2Responses(raw, renderedSwagger2(s, opts))
+	d, err := documentElementsSwagger2(s, opts)
+	if err != nil {
+		return "", err
+	}
+	slug, err := anchorFunc(opts)
+	if err != nil {
+		return "", err
+	}
+
+	// scopeDesc looks up an OAuth2 scope's description in the named
+	// security definition, for operation security requirements.
+	scopeDesc := func(scheme, scope string) string {
+		if sec := s.SecurityDefinitions[scheme]; sec != nil {
+			return sec.Scopes[scope]
+		}
+		return ""
+	}
+
+	// errorSchema is the definition the Errors section documents, or nil.
+	var errorSchema *spec.Schema
+	if sch, ok := s.Definitions[opts.ErrorSchemaName]; ok && opts.ErrorSchemaName != "" {
+		errorSchema = &sch
+	}
+	// errorsNote returns the "(see Errors)" note for a response schema that
+	// refers to errorSchema, or "".
+	errorsNote := func(sch *spec.Schema) string {
+		if errorSchema == nil || sch == nil || refName(sch.Ref.String()) != opts.ErrorSchemaName {
+			return ""
+		}
+		return fmt.Sprintf(" (see [Errors](#%s))", slug("Errors"))
+	}
+
+	// headersFor lists the headers an operation requires, for
+	// opts.RequiredHeadersSummary.
+	headersFor := func(ref swagger2Op) []string {
+		if !opts.RequiredHeadersSummary {
+			return nil
+		}
+		reqs := s.Security
+		if ref.Op.Security != nil {
+			reqs = ref.Op.Security
+		}
+		headerOf := func(scheme string) string {
+			sec := s.SecurityDefinitions[scheme]
+			if sec == nil || sec.Type != "apiKey" || sec.In != "header" {
+				return ""
+			}
+			return sec.Name
+		}
+		var params []string
+		for _, prm := range mergedParametersSwagger2(ref.PathItem, ref.Op) {
+			if prm.In == "header" && prm.Required {
+				params = append(params, prm.Name)
+			}
+		}
+		return requiredHeaders(reqs, headerOf, params)
+	}
+
+	operations := make([]swagger2Op, len(d.operations))
+	for i, op := range d.operations {
+		operations[i] = op.source.(swagger2Op)
 	}
+	m := &swagger2Markdown{
+		s:           s,
+		raw:         raw,
+		opts:        opts,
+		slug:        slug,
+		operations:  operations,
+		unknown:     unknown,
+		errorSchema: errorSchema,
+		rc: &swagger2RenderContext{
+			opts:       opts,
+			produces:   s.Produces,
+			consumes:   s.Consumes,
+			unknown:    unknown,
+			scopeDesc:  scopeDesc,
+			errorsNote: errorsNote,
+			headers:    headersFor,
+		},
+	}
+	return walkElements(d, m, opts)
+}
 
-	var b bytes.Buffer
+// swagger2Markdown is openAPI3Markdown for a Swagger 2.0 document.
+type swagger2Markdown struct {
+	s    *spec.Swagger
+	raw  []byte
+	opts Options
+	slug func(string) string
+	// operations are every rendered operation, with its anchor.
+	operations  []swagger2Op
+	unknown     map[*spec.Operation][]namedResponse
+	rc          *swagger2RenderContext
+	errorSchema *spec.Schema
+	// errorsWritten records that the Errors section, which sits between the
+	// endpoints and the schemas, is written.
+	errorsWritten bool
+	// users and rawSchemas feed the Schemas section.
+	users      map[string][]operationReference
+	rawSchemas map[string]json.RawMessage
+}
+
+// Overview writes everything before the first tag section: the overview,
+// authentication, servers, and tags, then the Endpoints by Tag heading.
+func (m *swagger2Markdown) Overview(w io.Writer, _ OverviewElement) error {
+	b, s, opts := w.(*bytes.Buffer), m.s, m.opts
 
 	// Overview
 	title := "-"
@@ -38,42 +167,58 @@ func swagger2ToMarkdown(data []byte) (md string, err error) {
 			version = s.Info.Version
 		}
 	}
-	fmt.Fprintf(&b, "# %s\n\n", title)
-	fmt.Fprintf(&b, "## Overview\n")
-	fmt.Fprintf(&b, "- Version: %s\n", version)
-	if s.Info != nil && s.Info.Description != "" {
-		fmt.Fprintf(&b, "- Description: %s\n", strings.TrimSpace(s.Info.Description))
+	if opts.IncludeBranding && s.Info != nil {
+		writeLogo(b, s.Info.Extensions)
+	}
+	fmt.Fprintf(b, "# %s\n\n", title)
+	fmt.Fprintf(b, "## Overview\n")
+	specVersion := "-"
+	if s.Swagger != "" {
+		specVersion = s.Swagger
 	}
+	desc := ""
+	if s.Info != nil {
+		desc = strings.TrimSpace(s.Info.Description)
+	}
+	writeDescriptionBlock(b, desc)
+	fmt.Fprintf(b, "- API Version: %s\n", version)
+	fmt.Fprintf(b, "- Swagger Version: %s\n", specVersion)
+	writeDescriptionBullet(b, desc)
 	if s.Info != nil && s.Info.Contact != nil {
 		if s.Info.Contact.Name != "" {
-			fmt.Fprintf(&b, "- Contact: %s\n", s.Info.Contact.Name)
+			fmt.Fprintf(b, "- Contact: %s\n", s.Info.Contact.Name)
 		}
 		if s.Info.Contact.Email != "" {
-			fmt.Fprintf(&b, "- Contact Email: %s\n", s.Info.Contact.Email)
+			fmt.Fprintf(b, "- Contact Email: %s\n", s.Info.Contact.Email)
 		}
 	}
 	if s.Info != nil && s.Info.License != nil && s.Info.License.Name != "" {
-		fmt.Fprintf(&b, "- License: %s\n", s.Info.License.Name)
+		fmt.Fprintf(b, "- License: %s\n", s.Info.License.Name)
+	}
+
+	if opts.IncludeChangelog {
+		writeChangelog(b, s.Extensions, opts.ChangelogKey)
 	}
 
 	// Authentication
-	fmt.Fprintf(&b, "\n## Authentication\n")
 	if len(s.SecurityDefinitions) == 0 {
-		fmt.Fprintf(&b, "- None defined\n")
+		writeEmptySection(b, "Authentication", opts)
 	} else {
-		for name, sec := range s.SecurityDefinitions {
-			line := fmt.Sprintf("- %s — type=%s", name, sec.Type)
+		fmt.Fprintf(b, "\n## Authentication\n")
+		for _, name := range sortedKeys(s.SecurityDefinitions) {
+			sec := s.SecurityDefinitions[name]
+			fmt.Fprintf(b, "- %s — type=%s", name, sec.Type)
 			if sec.Name != "" {
-				line += fmt.Sprintf(", name=%s", sec.Name)
+				fmt.Fprintf(b, ", name=%s", sec.Name)
 			}
 			if sec.In != "" {
-				line += fmt.Sprintf(", in=%s", sec.In)
+				fmt.Fprintf(b, ", in=%s", sec.In)
 			}
 			if sec.AuthorizationURL != "" {
-				line += fmt.Sprintf(", authUrl=%s", sec.AuthorizationURL)
+				fmt.Fprintf(b, ", authUrl=%s", sec.AuthorizationURL)
 			}
 			if sec.TokenURL != "" {
-				line += fmt.Sprintf(", tokenUrl=%s", sec.TokenURL)
+				fmt.Fprintf(b, ", tokenUrl=%s", sec.TokenURL)
 			}
 			if len(sec.Scopes) > 0 {
 				var scopes []string
@@ -85,189 +230,277 @@ func swagger2ToMarkdown(data []byte) (md string, err error) {
 					}
 				}
 				sort.Strings(scopes)
-				line += fmt.Sprintf(", scopes=[%s]", strings.Join(scopes, ", "))
+				fmt.Fprintf(b, ", scopes=[%s]", strings.Join(scopes, ", "))
 			}
-			fmt.Fprintln(&b, line)
+			b.WriteByte('\n')
 		}
 	}
+	if s.Security != nil {
+		fmt.Fprintf(b, "- Default security: %s\n", formatSecurityRequirements(s.Security, nil))
+	}
 
 	// Servers
-	fmt.Fprintf(&b, "\n## Servers\n")
-	hostLine := hostURL(s.Schemes, s.Host, s.BasePath)
-	if hostLine != "" {
-		fmt.Fprintf(&b, "- %s\n", hostLine)
+	if urls := hostURLs(s.Schemes, s.Host, s.BasePath); len(urls) > 0 {
+		fmt.Fprintf(b, "\n## Servers\n")
+		for _, u := range urls {
+			fmt.Fprintf(b, "- %s\n", u)
+		}
 	} else {
-		fmt.Fprintf(&b, "- None defined\n")
+		writeEmptySection(b, "Servers", opts)
 	}
 
 	// Tags
-	fmt.Fprintf(&b, "\n## Tags\n")
 	if len(s.Tags) == 0 {
-		fmt.Fprintf(&b, "- None defined\n")
+		writeEmptySection(b, "Tags", opts)
 	} else {
+		fmt.Fprintf(b, "\n## Tags\n")
 		for _, t := range s.Tags {
 			if t.Description != "" {
-				fmt.Fprintf(&b, "- %s — %s\n", t.Name, t.Description)
+				fmt.Fprintf(b, "- %s — %s\n", t.Name, t.Description)
 			} else {
-				fmt.Fprintf(&b, "- %s\n", t.Name)
+				fmt.Fprintf(b, "- %s\n", t.Name)
 			}
 		}
 	}
 
-	// Endpoints by Tag
-	fmt.Fprintf(&b, "\n## Endpoints by Tag\n")
-	type opRef struct {
-		Method string
-		Path   string
-		Op     *spec.Operation
+	if len(m.operations) > 0 || !opts.OmitEmptySections {
+		fmt.Fprintf(b, "\n## Endpoints by Tag\n")
 	}
-	tagged := map[string][]opRef{}
-	untagged := []opRef{}
-
-	paths := make([]string, 0, len(s.Paths.Paths))
-	for p := range s.Paths.Paths {
-		paths = append(paths, p)
-	}
-	sort.Strings(paths)
+	return nil
+}
 
-	for _, p := range paths {
-		pi := s.Paths.Paths[p]
-		ops := []struct {
-			method string
-			op     *spec.Operation
-		}{
-			{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put}, {"DELETE", pi.Delete},
-			{"PATCH", pi.Patch}, {"OPTIONS", pi.Options}, {"HEAD", pi.Head},
+// Section writes a tag's subheading, with its model index and the operations
+// listed under their primary tag, or starts the Schemas section.
+func (m *swagger2Markdown) Section(w io.Writer, sec SectionElement) error {
+	b, s, opts := w.(*bytes.Buffer), m.s, m.opts
+	if sec.Kind == SectionSchemas {
+		m.writeErrors(b)
+		fmt.Fprintf(b, "\n## Schemas\n")
+		if opts.EmitModelDiagram {
+			writeModelDiagram(b, diagramClassesSwagger2(s.Definitions), opts.ModelDiagramMaxNodes)
 		}
-		for _, it := range ops {
-			if it.op == nil {
-				continue
-			}
-			ref := opRef{Method: it.method, Path: p, Op: it.op}
-			if len(it.op.Tags) == 0 {
-				untagged = append(untagged, ref)
-				continue
-			}
-			for _, tag := range it.op.Tags {
-				tagged[tag] = append(tagged[tag], ref)
-			}
+		if opts.IncludeRawSchema {
+			m.rawSchemas = rawObjectAt(m.raw, "definitions")
+		}
+		if opts.SchemaUsageBackrefs {
+			m.users = schemaUsers(m.operations, func(o swagger2Op, rs refSet) {
+				rs.addOperationSwagger2(o.PathItem, o.Op, s.Definitions)
+			}, func(o swagger2Op) operationReference {
+				return operationReference{Method: o.Method, Path: o.Path, Anchor: o.Anchor}
+			})
 		}
+		return nil
 	}
 
-	tagNames := make([]string, 0, len(tagged))
-	for name := range tagged {
-		tagNames = append(tagNames, name)
+	writeSubheading(b, sec.Name, opts)
+	if sec.untagged {
+		return nil
 	}
-	sort.Strings(tagNames)
-	for _, name := range tagNames {
-		fmt.Fprintf(&b, "\n### %s\n", name)
-		for _, ref := range tagged[name] {
-			writeSwagger2Operation(&b, ref.Method, ref.Path, ref.Op, s.Produces, s.Consumes)
+	if opts.TagModelIndex {
+		models := refSet{}
+		for _, op := range sec.operations {
+			ref := op.source.(swagger2Op)
+			models.addOperationSwagger2(ref.PathItem, ref.Op, s.Definitions)
 		}
+		writeModelIndex(b, models.sorted(), m.slug, func(n string) bool {
+			_, ok := s.Definitions[n]
+			return ok && !opts.OmitSchemas
+		})
 	}
-
-	if len(untagged) > 0 {
-		fmt.Fprintf(&b, "\n### Untagged\n")
-		for _, ref := range untagged {
-			writeSwagger2Operation(&b, ref.Method, ref.Path, ref.Op, s.Produces, s.Consumes)
+	var references []operationReference
+	for _, op := range sec.operations {
+		if opts.PrimaryTagOnly && op.Tags[0] != sec.Name {
+			references = append(references, operationReference{op.Method, op.Path, op.Anchor, op.Tags[0]})
 		}
 	}
+	writeOperationReferences(b, references)
+	return nil
+}
+
+// Operation writes one operation.
+func (m *swagger2Markdown) Operation(w io.Writer, op OperationElement) error {
+	writeSwagger2Operation(w.(*bytes.Buffer), op.source.(swagger2Op), m.rc)
+	return nil
+}
 
-// Schemas (Definitions)
-	if len(s.Definitions) > 0 {
-		fmt.Fprintf(&b, "\n## Schemas\n")
-		names := make([]string, 0, len(s.Definitions))
-		for name := range s.Definitions {
-			names = append(names, name)
+// Schema writes one definition.
+func (m *swagger2Markdown) Schema(w io.Writer, el SchemaElement) error {
+	b, s, opts, name, sch := w.(*bytes.Buffer), m.s, m.opts, el.Name, el.source.(spec.Schema)
+	users, rawSchemas := m.users, m.rawSchemas
+
+	writeSchemaHeading(b, name, opts)
+	if sch.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", sch.Description)
+	}
+	if bounds := propertiesBounds(sch.MinProperties, sch.MaxProperties); bounds != "" {
+		fmt.Fprintf(b, "%s\n\n", bounds)
+	}
+	props, required := mergedPropertiesSwagger2(&sch, s.Definitions)
+	if len(props) > 0 {
+		fmt.Fprintf(b, "**Properties**\n")
+		propNames := make([]string, 0, len(props))
+		for pn := range props {
+			propNames = append(propNames, pn)
 		}
-		sort.Strings(names)
-		for _, name := range names {
-			sch := s.Definitions[name]
-			fmt.Fprintf(&b, "\n### %s\n", name)
-			if sch.Description != "" {
-				fmt.Fprintf(&b, "%s\n\n", sch.Description)
+		sort.Strings(propNames)
+		for _, pn := range propNames {
+			ps := props[pn]
+			typ := nonEmpty(byteFormatNoteSwagger2(&ps, binaryFileNote), nonEmpty(schemaSummarySwagger2(&ps), "-"))
+			desc := strings.TrimSpace(ps.Description)
+			req := ""
+			if contains(required, pn) {
+				req = " (required)"
 			}
-			if len(sch.Properties) > 0 {
-				fmt.Fprintf(&b, "**Properties**\n")
-				propNames := make([]string, 0, len(sch.Properties))
-				for pn := range sch.Properties {
-					propNames = append(propNames, pn)
-				}
-				sort.Strings(propNames)
-				for _, pn := range propNames {
-					ps := sch.Properties[pn]
-					typ := nonEmpty(schemaSummarySwagger2(&ps), "-")
-					desc := strings.TrimSpace(ps.Description)
-					req := ""
-					if contains(sch.Required, pn) {
-						req = " (required)"
-					}
-					def := defaultAsString(ps.Default)
-					enum := enumAsString(ps.Enum)
-					line := fmt.Sprintf("- `%s` (%s)%s", pn, typ, req)
-					if desc != "" {
-						line += fmt.Sprintf(" — %s", desc)
-					}
-					if def != "" {
-						line += fmt.Sprintf(" [default: %s]", def)
-					}
-					if enum != "" {
-						line += fmt.Sprintf(" [enum: %s]", enum)
-					}
-					fmt.Fprintln(&b, line)
-				}
+			if swagger2Deprecated(ps.Extensions) {
+				req += deprecatedNote(deprecationReason(ps.Description, ps.Extensions))
+			}
+			def := defaultAsString(ps.Default)
+			enum := ""
+			enumList := enumItems(ps.Extensions, ps.Enum, opts)
+			if enumList == nil {
+				enum = enumAsString(ps.Enum)
+			}
+			fmt.Fprintf(b, "- `%s` %s%s", pn, typeLabel(typ, ps.Title, ps.Ref.String() != "", opts.PreferSchemaTitles), req)
+			if desc != "" {
+				fmt.Fprintf(b, " — %s", desc)
+			}
+			if def != "" {
+				fmt.Fprintf(b, " [default: %s]", literal(def))
+			}
+			if bounds := numericBounds(ps.Minimum, ps.Maximum, ps.ExclusiveMinimum, ps.ExclusiveMaximum); bounds != "" {
+				fmt.Fprintf(b, " %s", bounds)
+			}
+			if constraints := formatConstraints(ps.MinLength, ps.MaxLength, ps.Pattern); constraints != "" {
+				fmt.Fprintf(b, " %s", constraints)
+			}
+			if enum != "" {
+				fmt.Fprintf(b, " [enum: %s]", enum)
 			}
-			// Schema example (standard or vendor)
-			if sch.Example != nil {
-				writeExampleFence(&b, "Example", "application/json", sch.Example)
-			} else if v, ok := sch.VendorExtensible.Extensions["x-example"]; ok {
-				writeExampleFence(&b, "Example", "application/json", v)
+			b.WriteByte('\n')
+			if enumList != nil {
+				writeEnumItems(b, enumList)
 			}
 		}
 	}
+	if ap := sch.AdditionalProperties; ap != nil {
+		if ap.Schema != nil {
+			writeAdditionalProperties(b, nil, nonEmpty(schemaSummarySwagger2(ap.Schema), "object"))
+		} else {
+			writeAdditionalProperties(b, &ap.Allows, "")
+		}
+	}
+	if opts.SplitReadWrite {
+		// Swagger 2.0 has readOnly but no writeOnly.
+		writeDirectionalRequired(b, required, func(pn string) (bool, bool) {
+			return props[pn].ReadOnly, false
+		})
+	}
+	// Schema example (standard or vendor)
+	if ex := schemaExampleSwagger2(&sch); ex != nil {
+		writeExampleFence(b, "Example", "application/json", ex)
+	} else if v, ok := sch.VendorExtensible.Extensions["x-example"]; ok {
+		writeExampleFence(b, "Example", "application/json", v)
+	}
+	writeSchemaUsers(b, users[name])
+	if opts.IncludeRawSchema {
+		writeRawSchema(b, rawSchemas[name], sch)
+	}
+	return nil
+}
 
-	// Examples (basic)
-	fmt.Fprintf(&b, "\n## Examples\n")
-	for _, p := range paths {
-		pi := s.Paths.Paths[p]
-		ops := []struct {
-			method string
-			op     *spec.Operation
-		}{
-			{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put}, {"DELETE", pi.Delete},
-			{"PATCH", pi.Patch}, {"OPTIONS", pi.Options}, {"HEAD", pi.Head},
+// writeErrors writes the Errors section once, when an error schema is set.
+func (m *swagger2Markdown) writeErrors(b *bytes.Buffer) {
+	if m.errorsWritten || m.errorSchema == nil {
+		return
+	}
+	m.errorsWritten = true
+	opts := m.opts
+	schemaLink := func(name string) string {
+		if opts.OmitSchemas {
+			return name
 		}
-		for _, it := range ops {
-			if it.op == nil || it.op.Responses == nil {
+		return fmt.Sprintf("[%s](#%s)", name, m.slug(name))
+	}
+	writeErrorsSection(b, opts.ErrorSchemaName, m.errorSchema.Description, errorFieldsSwagger2(m.errorSchema, m.s.Definitions), errorUsesSwagger2(m.operations, m.unknown, opts.ErrorSchemaName), schemaLink, opts)
+}
+
+// finish writes what follows the schemas: the Errors section when there was
+// no Schemas section, the Examples index, and the raw spec appendix.
+func (m *swagger2Markdown) finish(b *bytes.Buffer) error {
+	s, raw, opts, operations := m.s, m.raw, m.opts, m.operations
+	m.writeErrors(b)
+
+	// Examples index (opt-in)
+	if opts.ExamplesSection {
+		start := b.Len()
+		fmt.Fprintf(b, "\n## Examples\n")
+		body := b.Len()
+		for _, ref := range operations {
+			if ref.Op.Responses == nil {
 				continue
 			}
-			for code, r := range it.op.Responses.StatusCodeResponses {
+			codes := make([]int, 0, len(ref.Op.Responses.StatusCodeResponses))
+			for code := range ref.Op.Responses.StatusCodeResponses {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			for _, code := range codes {
+				r := ref.Op.Responses.StatusCodeResponses[code]
 				if len(r.Headers) == 0 && r.Schema == nil && len(r.Examples) == 0 {
 					continue
 				}
 				if len(r.Examples) > 0 {
-					fmt.Fprintf(&b, "- %s %s %d — has inline examples\n", it.method, p, code)
+					fmt.Fprintf(b, "- %s %s %d — has inline examples\n", ref.Method, ref.Path, code)
 				}
 			}
 		}
+		if b.Len() == body && opts.OmitEmptySections {
+			b.Truncate(start)
+		}
 	}
 
-	return b.String(), nil
+	if opts.AppendRawSpec {
+		writeSourceSpecAppendix(b, raw, s, opts)
+	}
+	return nil
+}
+
+// swagger2RenderContext holds what every operation of one Swagger 2.0 render
+// shares.
+type swagger2RenderContext struct {
+	opts Options
+	// produces and consumes are the document-level media types.
+	produces, consumes []string
+	// unknown holds the non-numeric responses the parsed model drops, by
+	// operation.
+	unknown map[*spec.Operation][]namedResponse
+	// scopeDesc looks up an OAuth2 scope's description.
+	scopeDesc func(scheme, scope string) string
+	// errorsNote returns the note that replaces a response schema's details
+	// when it is the error schema.
+	errorsNote func(*spec.Schema) string
+	// headers lists the headers an operation requires, for
+	// Options.RequiredHeadersSummary.
+	headers func(swagger2Op) []string
 }
 
-func writeSwagger2Operation(b *bytes.Buffer, method, path string, op *spec.Operation, globalProduces, globalConsumes []string) {
-	fmt.Fprintf(b, "\n#### %s %s\n", method, path)
-	if op.Summary != "" {
-		fmt.Fprintf(b, "%s\n\n", op.Summary)
+// writeSwagger2Operation renders one operation.
+func writeSwagger2Operation(b *bytes.Buffer, ref swagger2Op, rc *swagger2RenderContext) {
+	method, path, anchor, pi, op := ref.Method, ref.Path, ref.Anchor, ref.PathItem, ref.Op
+	globalProduces, globalConsumes, unknown := rc.produces, rc.consumes, rc.unknown[ref.Op]
+	scopeDesc, errorsNote, headers, opts := rc.scopeDesc, rc.errorsNote, rc.headers(ref), rc.opts
+	blankLine(b)
+	if opts.OperationMarkers {
+		writeOperationMarker(b, op.ID, method, path)
 	}
-	if op.Description != "" {
-		fmt.Fprintf(b, "%s\n\n", op.Description)
+	if explicitOperationAnchor(opts) {
+		fmt.Fprintf(b, "<a id=\"%s\"></a>\n", anchor)
 	}
-
-	// Operation ID
-	if op.ID != "" {
-		fmt.Fprintf(b, "_Operation ID_: `%s`\n\n", op.ID)
+	fmt.Fprintf(b, "#### %s%s %s\n", methodBadge(method, opts), method, headingPath(path, func(name string) string { return pathParamTypeSwagger2(pi, op, name) }, opts))
+	if op.Deprecated {
+		writeDeprecationBadge(b, deprecationReason(op.Description, op.Extensions))
 	}
+	writeOperationIntro(b, op.Summary, op.Description, op.ID)
 
 	// Media types
 	produces := op.Produces
@@ -278,131 +511,393 @@ func writeSwagger2Operation(b *bytes.Buffer, method, path string, op *spec.Opera
 	if len(consumes) == 0 {
 		consumes = globalConsumes
 	}
-	if len(produces) > 0 {
-		fmt.Fprintf(b, "**Produces**\n")
-		for _, mt := range produces {
-			fmt.Fprintf(b, "- %s\n", mt)
-		}
-		fmt.Fprintln(b)
+	writeMediaTypeList(b, "Produces", produces, opts)
+	writeMediaTypeList(b, "Consumes", consumes, opts)
+	declaresConsumes := len(consumes) > 0
+	consumes = selectedMediaTypes(consumes, opts)
+
+	// Security (overrides the document default when present)
+	if op.Security != nil {
+		blankLine(b)
+		fmt.Fprintf(b, "**Security**\n- %s\n", formatSecurityRequirements(op.Security, scopeDesc))
 	}
-	if len(consumes) > 0 {
-		fmt.Fprintf(b, "**Consumes**\n")
-		for _, mt := range consumes {
-			fmt.Fprintf(b, "- %s\n", mt)
+
+	writeRequiredHeaders(b, headers)
+
+	// Parameters (PathItem + Operation; the body parameter gets its own
+	// Request Body section)
+	var body *spec.Parameter
+	merged := mergedParametersSwagger2(pi, op)
+	params := make([]spec.Parameter, 0, len(merged))
+	for i, prm := range merged {
+		if prm.In == "body" {
+			if body == nil {
+				body = &merged[i]
+			}
+			continue
 		}
-		fmt.Fprintln(b)
+		params = append(params, prm)
 	}
-
-	// Parameters
-	if len(op.Parameters) > 0 {
+	var deprecated []spec.Parameter
+	if opts.GroupDeprecatedParameters {
+		params, deprecated = splitDeprecated(params, swagger2ParameterDeprecated)
+	}
+	if opts.GroupParametersByLocation {
+		writeParameterGroups(b, params, func(prm spec.Parameter) string { return prm.In }, func(prm spec.Parameter) {
+			writeSwagger2Parameter(b, prm)
+		})
+	} else if len(params) > 0 {
+		blankLine(b)
 		fmt.Fprintf(b, "**Parameters**\n")
-		for _, prm := range op.Parameters {
-			loc, name := prm.In, prm.Name
-			req := ""
-			if prm.Required {
-				req = " (required)"
-			}
-			typ := prm.Type
-			if typ == "" && prm.Schema != nil && len(prm.Schema.Type) > 0 {
-				typ = strings.Join(prm.Schema.Type, ",")
-			}
-			desc := strings.TrimSpace(prm.Description)
-			def := defaultAsString(prm.Default)
-			enum := enumAsString(prm.Enum)
+		for _, prm := range params {
+			writeSwagger2Parameter(b, prm)
+		}
+	}
+	writeDeprecatedParameters(b, deprecated, func(prm spec.Parameter) {
+		writeSwagger2Parameter(b, prm)
+	})
 
-			line := fmt.Sprintf("- %s `%s` (%s)%s", loc, name, nonEmpty(typ, "-"), req)
-			if desc != "" {
-				line += fmt.Sprintf(" — %s", desc)
-			}
-			if def != "" {
-				line += fmt.Sprintf(" [default: %s]", def)
-			}
-			if enum != "" {
-				line += fmt.Sprintf(" [enum: %s]", enum)
-			}
-			fmt.Fprintln(b, line)
+	// Request Body (Swagger 2.0: the "in: body" parameter)
+	if body != nil {
+		blankLine(b)
+		fmt.Fprintf(b, "**Request Body**\n")
+		if note := byteFormatNoteSwagger2(body.Schema, binaryUploadNote); note != "" {
+			fmt.Fprintf(b, "- `%s` — %s", body.Name, note)
+		} else {
+			fmt.Fprintf(b, "- `%s` — schema: %s", body.Name, nonEmpty(schemaSummarySwagger2(body.Schema), "-"))
+		}
+		if body.Required {
+			b.WriteString(" (required)")
+		}
+		if desc := strings.TrimSpace(body.Description); desc != "" {
+			fmt.Fprintf(b, " — %s", desc)
 		}
+		b.WriteByte('\n')
 	}
 
 	// Request example (Swagger 2.0: body parameter schema.example)
-	var bodySchema *spec.Schema
-	for _, prm := range op.Parameters {
-		if prm.In == "body" && prm.Schema != nil {
-			bodySchema = prm.Schema
-			break
-		}
-	}
-	if bodySchema != nil {
-		// Prefer schema-level Example if present, else look at items when array.
-		var ex any
-		if bodySchema.Example != nil {
-			ex = bodySchema.Example
-		} else if bodySchema.Items != nil && bodySchema.Items.Schema != nil && bodySchema.Items.Schema.Example != nil {
-			ex = bodySchema.Items.Schema.Example
-		}
-		// Vendor extensions: x-example or x-examples
-		if ex == nil {
-			if v, ok := bodySchema.VendorExtensible.Extensions["x-example"]; ok {
-				ex = v
-			}
-		}
-		if ex != nil {
+	if body != nil {
+		if ex := bodyExampleSwagger2(body); ex != nil {
 			if len(consumes) > 0 {
 				for _, mt := range consumes {
 					writeExampleFence(b, "Request example ("+mt+")", mt, ex)
 				}
-			} else {
+			} else if !declaresConsumes {
 				writeExampleFence(b, "Request example", "", ex)
 			}
 		}
 	}
 
 	// Responses
-	if op.Responses != nil && (len(op.Responses.StatusCodeResponses) > 0 || op.Responses.Default != nil) {
-		fmt.Fprintf(b, "\n**Responses**\n")
+	if op.Responses != nil && (len(op.Responses.StatusCodeResponses) > 0 || op.Responses.Default != nil) || len(unknown) > 0 {
+		blankLine(b)
+		fmt.Fprintf(b, "**Responses**\n")
 		var codes []int
-		for code := range op.Responses.StatusCodeResponses {
-			codes = append(codes, code)
+		if op.Responses != nil {
+			for code := range op.Responses.StatusCodeResponses {
+				codes = append(codes, code)
+			}
 		}
 		sort.Ints(codes)
 		for _, code := range codes {
 			r := op.Responses.StatusCodeResponses[code]
-			desc := strings.TrimSpace(r.Description)
-			if desc == "" {
-				desc = "No description"
-			}
-			line := fmt.Sprintf("- %d — %s", code, desc)
-			if r.Schema != nil {
-				if summary := schemaSummarySwagger2(r.Schema); summary != "" {
-					line += fmt.Sprintf(" (schema: %s)", summary)
-				}
-			}
-			fmt.Fprintln(b, line)
+			writeSwagger2Response(b, strconv.Itoa(code), &r, errorsNote, opts)
+		}
+		for _, nr := range unknown {
+			writeSwagger2Response(b, nr.Key, &nr.Response, errorsNote, opts)
+		}
+		if op.Responses != nil && op.Responses.Default != nil {
+			writeSwagger2Response(b, "default", op.Responses.Default, errorsNote, opts)
+		}
+	}
 
-			// Render response examples by media type if present.
-			if len(r.Examples) > 0 {
-				var mts []string
-				for mt := range r.Examples {
-					mts = append(mts, mt)
-				}
-				sort.Strings(mts)
-				for _, mt := range mts {
-					writeExampleFence(b, fmt.Sprintf("Response example (%d, %s)", code, mt), mt, r.Examples[mt])
-				}
+	writeCodeSamples(b, op.Extensions, opts.CodeSamplesKey)
+}
+
+// bodyExampleSwagger2 returns the example of a body parameter: its schema's
+// example (see schemaExampleSwagger2), else the schema's x-example
+// extension. It returns nil when there is none.
+func bodyExampleSwagger2(body *spec.Parameter) any {
+	bodySchema := body.Schema
+	if bodySchema == nil {
+		return nil
+	}
+	if ex := schemaExampleSwagger2(bodySchema); ex != nil {
+		return ex
+	}
+	return bodySchema.VendorExtensible.Extensions["x-example"]
+}
+
+// schemaExampleSwagger2 returns a schema's example or, for an array without
+// one, a one-element array of its items' example, so the example keeps the
+// array's shape. It returns nil when there is neither.
+func schemaExampleSwagger2(s *spec.Schema) any {
+	if s.Example != nil {
+		return s.Example
+	}
+	if s.Type.Contains("array") && s.Items != nil && s.Items.Schema != nil && s.Items.Schema.Example != nil {
+		return []any{s.Items.Schema.Example}
+	}
+	return nil
+}
+
+// mergedParametersSwagger2 returns the parameters that apply to op: those
+// shared by its path item, then its own. An operation parameter replaces a
+// path-level one with the same name and location.
+func mergedParametersSwagger2(pi *spec.PathItem, op *spec.Operation) []spec.Parameter {
+	if pi == nil || len(pi.Parameters) == 0 {
+		return op.Parameters
+	}
+	overridden := func(prm spec.Parameter) bool {
+		for _, own := range op.Parameters {
+			if own.Name == prm.Name && own.In == prm.In {
+				return true
 			}
 		}
-		if op.Responses.Default != nil {
-			desc := strings.TrimSpace(op.Responses.Default.Description)
-			if desc == "" {
-				desc = "No description"
+		return false
+	}
+	params := make([]spec.Parameter, 0, len(pi.Parameters)+len(op.Parameters))
+	for _, prm := range pi.Parameters {
+		if !overridden(prm) {
+			params = append(params, prm)
+		}
+	}
+	return append(params, op.Parameters...)
+}
+
+// pathParamTypeSwagger2 is pathParamTypeOpenAPI3 for Swagger 2.0.
+func pathParamTypeSwagger2(pi *spec.PathItem, op *spec.Operation, name string) string {
+	for _, prm := range mergedParametersSwagger2(pi, op) {
+		if prm.In == "path" && prm.Name == name {
+			return prm.Type
+		}
+	}
+	return ""
+}
+
+// swagger2ParameterDeprecated reports whether prm is deprecated. Swagger 2.0
+// has no deprecated field on parameters; the common x-deprecated extension
+// stands in for it.
+func swagger2ParameterDeprecated(prm spec.Parameter) bool {
+	return swagger2Deprecated(prm.Extensions)
+}
+
+// swagger2Deprecated reports whether exts carry x-deprecated: true, the
+// stand-in for the deprecated field Swagger 2.0 parameters and schemas lack.
+func swagger2Deprecated(exts spec.Extensions) bool {
+	dep, _ := exts["x-deprecated"].(bool)
+	return dep
+}
+
+// writeSwagger2Parameter emits the list entry for one non-body parameter.
+func writeSwagger2Parameter(b *bytes.Buffer, prm spec.Parameter) {
+	loc, name := prm.In, prm.Name
+	req := ""
+	if prm.Required {
+		req = " (required)"
+	}
+	typ := prm.Type
+	if typ != "" && prm.Format != "" {
+		// Same "type (format)" label as schemaSummarySwagger2.
+		typ = fmt.Sprintf("%s (%s)", typ, literal(prm.Format))
+	}
+	if typ == "" && prm.Schema != nil && len(prm.Schema.Type) > 0 {
+		typ = strings.Join(prm.Schema.Type, ",")
+	}
+	desc := strings.TrimSpace(prm.Description)
+	def := defaultAsString(prm.Default)
+	enum := enumAsString(prm.Enum)
+
+	if prm.AllowEmptyValue {
+		req += " (allows empty)"
+	}
+	if swagger2ParameterDeprecated(prm) {
+		req += " (deprecated)"
+	}
+	fmt.Fprintf(b, "- %s `%s` (%s)%s", loc, name, nonEmpty(typ, "-"), req)
+	if desc != "" {
+		fmt.Fprintf(b, " — %s", desc)
+	}
+	if def != "" {
+		fmt.Fprintf(b, " [default: %s]", literal(def))
+	}
+	if bounds := numericBounds(prm.Minimum, prm.Maximum, prm.ExclusiveMinimum, prm.ExclusiveMaximum); bounds != "" {
+		fmt.Fprintf(b, " %s", bounds)
+	}
+	if constraints := formatConstraints(prm.MinLength, prm.MaxLength, prm.Pattern); constraints != "" {
+		fmt.Fprintf(b, " %s", constraints)
+	}
+	if enum != "" {
+		fmt.Fprintf(b, " [enum: %s]", enum)
+	}
+	b.WriteByte('\n')
+}
+
+// byteFormatNoteSwagger2 is byteFormatNote for an inline Swagger 2.0 schema;
+// named schemas keep their ref.
+func byteFormatNoteSwagger2(sch *spec.Schema, binaryNote string) string {
+	if sch == nil || sch.Ref.String() != "" {
+		return ""
+	}
+	return byteFormatNote(sch.Type, sch.Format, binaryNote)
+}
+
+// writeMediaTypeList writes a Produces or Consumes list of the media types
+// passing opts.MediaTypeFilter, noting when the filter removed them all.
+func writeMediaTypeList(b *bytes.Buffer, heading string, mts []string, opts Options) {
+	if len(mts) == 0 {
+		return
+	}
+	blankLine(b)
+	fmt.Fprintf(b, "**%s**\n", heading)
+	selected := selectedMediaTypes(mts, opts)
+	if len(selected) == 0 {
+		fmt.Fprintf(b, "- %s\n", noMatchingMediaTypes)
+	}
+	for _, mt := range selected {
+		fmt.Fprintf(b, "- %s\n", mt)
+	}
+}
+
+// writeSwagger2Response writes a response's list entry followed by its
+// examples, one per media type passing opts.MediaTypeFilter. Numbered,
+// named, and default responses are all written this way.
+func writeSwagger2Response(b *bytes.Buffer, code string, r *spec.Response, errorsNote func(*spec.Schema) string, opts Options) {
+	writeSwagger2ResponseLine(b, code, r, errorsNote)
+	for _, mt := range selectedMediaTypes(sortedKeys(r.Examples), opts) {
+		writeExampleFence(b, fmt.Sprintf("Response example (%s, %s)", code, mt), mt, r.Examples[mt])
+	}
+}
+
+// writeSwagger2ResponseLine emits the summary bullet for one response.
+func writeSwagger2ResponseLine(b *bytes.Buffer, code string, r *spec.Response, errorsNote func(*spec.Schema) string) {
+	fmt.Fprintf(b, "- %s — %s", responseLabel(code), nonEmpty(strings.TrimSpace(r.Description), "No description"))
+	if r.Schema != nil {
+		if note := byteFormatNoteSwagger2(r.Schema, binaryBodyNote); note != "" {
+			fmt.Fprintf(b, " (%s)", note)
+		} else if summary := schemaSummarySwagger2(r.Schema); summary != "" {
+			fmt.Fprintf(b, " (schema: %s)%s", summary, errorsNote(r.Schema))
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// namedResponse is a Swagger 2.0 response whose key is not an integer status
+// code (e.g. "Success"). go-openapi silently drops these when decoding.
+type namedResponse struct {
+	Key      string
+	Response spec.Response
+}
+
+// recoverSwagger2Responses re-reads the raw document for response keys that
+// go-openapi dropped. Keys that are status codes padded with whitespace
+// ("200 ") are restored into the operation's StatusCodeResponses unless that
+// code is already present; anything else is returned per operation so it can
+// be rendered verbatim after the numeric codes. s itself is not modified:
+// the codes are restored into a copy of it, with copies of its operations,
+// which is returned with the per-operation responses keyed by those copies.
+func recoverSwagger2Responses(data []byte, s *spec.Swagger) (*spec.Swagger, map[*spec.Operation][]namedResponse) {
+	var raw struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if json.Unmarshal(data, &raw) != nil || s.Paths == nil {
+		return s, nil
+	}
+	doc := *s
+	paths := *s.Paths
+	paths.Paths = make(map[string]spec.PathItem, len(s.Paths.Paths))
+	for p, pi := range s.Paths.Paths {
+		paths.Paths[p] = copyOperationsSwagger2(pi)
+	}
+	doc.Paths = &paths
+
+	unknown := map[*spec.Operation][]namedResponse{}
+	for p, methods := range raw.Paths {
+		pi, ok := paths.Paths[p]
+		if !ok {
+			continue
+		}
+		for method, opData := range methods {
+			op := swagger2Operation(&pi, method)
+			if op == nil {
+				continue
+			}
+			var rawOp struct {
+				Responses map[string]json.RawMessage `json:"responses"`
 			}
-			line := fmt.Sprintf("- default — %s", desc)
-			if op.Responses.Default.Schema != nil {
-				if summary := schemaSummarySwagger2(op.Responses.Default.Schema); summary != "" {
-					line += fmt.Sprintf(" (schema: %s)", summary)
+			if json.Unmarshal(opData, &rawOp) != nil {
+				continue
+			}
+			// Sorted so that colliding padded keys resolve the same way every run.
+			for _, key := range sortedKeys(rawOp.Responses) {
+				respData := rawOp.Responses[key]
+				if key == "default" || strings.HasPrefix(key, "x-") {
+					continue
 				}
+				if _, err := strconv.Atoi(key); err == nil {
+					continue
+				}
+				var r spec.Response
+				if json.Unmarshal(respData, &r) != nil {
+					continue
+				}
+				if code, err := strconv.Atoi(strings.TrimSpace(key)); err == nil {
+					if op.Responses == nil {
+						op.Responses = &spec.Responses{}
+					}
+					if op.Responses.StatusCodeResponses == nil {
+						op.Responses.StatusCodeResponses = map[int]spec.Response{}
+					}
+					if _, exists := op.Responses.StatusCodeResponses[code]; !exists {
+						op.Responses.StatusCodeResponses[code] = r
+					}
+					continue
+				}
+				unknown[op] = append(unknown[op], namedResponse{Key: strings.TrimSpace(key), Response: r})
 			}
-			fmt.Fprintln(b, line)
 		}
 	}
+	for _, list := range unknown {
+		sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	}
+	return &doc, unknown
+}
+
+// copyOperationsSwagger2 returns pi with each operation replaced by a copy
+// whose Responses can be changed without touching the original.
+func copyOperationsSwagger2(pi spec.PathItem) spec.PathItem {
+	for _, op := range []**spec.Operation{&pi.Get, &pi.Put, &pi.Post, &pi.Delete, &pi.Options, &pi.Head, &pi.Patch} {
+		if *op == nil {
+			continue
+		}
+		c := **op
+		if c.Responses != nil {
+			r := *c.Responses
+			r.StatusCodeResponses = maps.Clone(r.StatusCodeResponses)
+			c.Responses = &r
+		}
+		*op = &c
+	}
+	return pi
+}
+
+// swagger2Operation returns the operation of pi for a lower-case method key.
+func swagger2Operation(pi *spec.PathItem, method string) *spec.Operation {
+	switch method {
+	case "get":
+		return pi.Get
+	case "post":
+		return pi.Post
+	case "put":
+		return pi.Put
+	case "delete":
+		return pi.Delete
+	case "patch":
+		return pi.Patch
+	case "options":
+		return pi.Options
+	case "head":
+		return pi.Head
+	}
+	return nil
 }
@@ -0,0 +1,141 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Post-processing: hard-wrap prose lines at a fixed column.
+
+// wrapMarkdown wraps prose lines in md that are longer than width. Lines
+// inside fenced code blocks, headings, table rows, and HTML comments are
+// copied verbatim. List items keep their marker on the first line and
+// continuation lines are indented to align with the item text, so the
+// wrapped output renders identically.
+func wrapMarkdown(md string, width int) string {
+	if width <= 0 {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	var out strings.Builder
+	inFence := false
+	for i, line := range lines {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			out.WriteString(line)
+			continue
+		}
+		if inFence || utf8.RuneCountInString(line) <= width || !isWrappableLine(trimmed) {
+			out.WriteString(line)
+			continue
+		}
+		out.WriteString(wrapLine(line, width))
+	}
+	return out.String()
+}
+
+// isWrappableLine reports whether a (trimmed) line holds prose that may be
+// wrapped without changing how it renders.
+func isWrappableLine(trimmed string) bool {
+	switch {
+	case trimmed == "":
+		return false
+	case strings.HasPrefix(trimmed, "#"):
+		return false
+	case strings.HasPrefix(trimmed, "|"):
+		return false
+	case strings.HasPrefix(trimmed, "<"):
+		return false
+	}
+	return true
+}
+
+// wrapLine breaks a single line on word boundaries so that no output line
+// exceeds width, except where a single word is itself longer than width. A
+// word that would start a new Markdown block at the beginning of a line,
+// such as "-" or "1.", is never moved to one: the word before it is carried
+// down with it, or when that is not possible it stays on the current line.
+func wrapLine(line string, width int) string {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	body := line[indent:]
+	hang := indent
+	// keep is how many words the first line holds at least, so a list
+	// marker is never left without its text.
+	keep := 1
+	if marker := listMarker(body); marker != "" {
+		hang += len(marker)
+		keep = 2
+	}
+
+	var lines [][]string
+	var cur []string
+	col := indent
+	for _, w := range strings.Fields(body) {
+		n := utf8.RuneCountInString(w)
+		if len(cur) > 0 && col+1+n > width {
+			minWords := 1
+			if len(lines) == 0 {
+				minWords = keep
+			}
+			if !startsBlock(w) {
+				lines, cur, col = append(lines, cur), nil, hang
+			} else if prev := cur[len(cur)-1]; len(cur) > minWords && !startsBlock(prev) {
+				lines, cur, col = append(lines, cur[:len(cur)-1]), []string{prev}, hang+utf8.RuneCountInString(prev)
+			}
+		}
+		if len(cur) > 0 {
+			col++
+		}
+		cur = append(cur, w)
+		col += n
+	}
+	lines = append(lines, cur)
+
+	var out strings.Builder
+	for i, words := range lines {
+		if i > 0 {
+			out.WriteByte('\n')
+			out.WriteString(strings.Repeat(" ", hang))
+		} else {
+			out.WriteString(strings.Repeat(" ", indent))
+		}
+		out.WriteString(strings.Join(words, " "))
+	}
+	return out.String()
+}
+
+// startsBlock reports whether a line beginning with the word w would open a
+// new block, such as a list item, heading, blockquote, code fence, or
+// thematic break, instead of continuing the paragraph above it.
+func startsBlock(w string) bool {
+	switch {
+	case w == "+" || w == "*":
+		return true
+	case strings.HasPrefix(w, ">"), strings.HasPrefix(w, "<"), strings.HasPrefix(w, "```"), strings.HasPrefix(w, "~~~"):
+		return true
+	case strings.Trim(w, "#") == "" && len(w) <= 6:
+		return true
+	case strings.Trim(w, "-") == "", strings.Trim(w, "=") == "":
+		// A bullet, or a setext heading underline or thematic break.
+		return true
+	case len(w) >= 3 && (strings.Trim(w, "*") == "" || strings.Trim(w, "_") == ""):
+		return true
+	}
+	digits := len(w) - len(strings.TrimLeft(w, "0123456789"))
+	return digits > 0 && digits <= 9 && len(w) == digits+1 && (w[digits] == '.' || w[digits] == ')')
+}
+
+// listMarker returns the bullet marker (including its trailing space) that
+// starts body, or "" if body is not a list item.
+func listMarker(body string) string {
+	for _, m := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(body, m) {
+			return m
+		}
+	}
+	return ""
+}